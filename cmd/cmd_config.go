@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect CLI configuration",
+	Long: `Commands for inspecting the effective configuration, which is the user config
+(~/.ruslan-cli/config.yaml) with the nearest .ruslan-cli.yaml merged over it.`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the effective configuration",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		showOrigin, _ := cmd.Flags().GetBool("show-origin")
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		if showOrigin {
+			type origin struct {
+				Key    string `json:"key" yaml:"key"`
+				Value  string `json:"value" yaml:"value"`
+				Origin string `json:"origin" yaml:"origin"`
+			}
+			origins := []origin{}
+			view := &output.Text{Rows: output.NewTable("Origin", "Key", "Value")}
+			for _, s := range cfg.Settings() {
+				origins = append(origins, origin{Key: s.Key, Value: s.Value, Origin: s.Origin})
+				view.Linef("%s\t%s=%s", s.Origin, s.Key, s.Value)
+				view.Rows.Append(s.Origin, s.Key, s.Value)
+			}
+			return out.Print(origins, view)
+		}
+
+		// Never print saved tokens
+		redacted := *cfg
+		redacted.Environments = make(map[string]*config.Environment, len(cfg.Environments))
+		for name, env := range cfg.Environments {
+			e := *env
+			if e.Token != "" {
+				e.Token = "<redacted>"
+			}
+			redacted.Environments[name] = &e
+		}
+
+		// Round-trip through YAML so that JSON output uses the config file's keys
+		data, err := yaml.Marshal(&redacted)
+		if err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+		var result map[string]interface{}
+		if err := yaml.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("failed to encode config: %w", err)
+		}
+
+		view := &output.Text{Rows: output.NewTable("Key", "Value")}
+		view.Lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		for _, s := range cfg.Settings() {
+			value := s.Value
+			if strings.HasSuffix(s.Key, ".token") && value != "" {
+				value = "<redacted>"
+			}
+			view.Rows.Append(s.Key, value)
+		}
+		return out.Print(result, view)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configViewCmd)
+
+	configViewCmd.Flags().Bool("show-origin", false, "show the file each value came from")
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+var secretsDiffCmd = &cobra.Command{
+	Use:   "diff [old] [new]",
+	Short: "Compare two secrets or folders",
+	Long: `Compare two secrets, each given as [env:]path[@version]. The environment
+defaults to the selected one and the version to the latest.
+
+Values are masked unless --show-values is given. With --recursive both paths
+are folders and every secret below them is compared by relative path.
+
+Like diff(1) it exits with status 0 when the secrets are the same, 1 when
+there are differences and 2 when they couldn't be compared, so CI can tell
+drift from a failure.`,
+	Example: `  ruslan-cli secrets diff dev:secret/myapp@3 prod:secret/myapp
+  ruslan-cli secrets diff secret/myapp@3 secret/myapp@4
+  ruslan-cli secrets diff -r dev:secret/myapp prod:secret/myapp`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		differs, err := runDiff(cmd, args)
+		if err != nil {
+			return failWith(cmd, 2, err)
+		}
+		if differs {
+			return exitWith(cmd, 1)
+		}
+		return nil
+	},
+}
+
+// runDiff compares the secrets given in args, prints the differences and
+// reports whether there were any
+func runDiff(cmd *cobra.Command, args []string) (bool, error) {
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	showValues, _ := cmd.Flags().GetBool("show-values")
+
+	out, err := newPrinter(cmd)
+	if err != nil {
+		return false, err
+	}
+
+	oldRef, err := vault.ParseSecretRef(args[0])
+	if err != nil {
+		return false, err
+	}
+	newRef, err := vault.ParseSecretRef(args[1])
+	if err != nil {
+		return false, err
+	}
+	if recursive && (oldRef.Version > 0 || newRef.Version > 0) {
+		return false, errors.New("versions can't be used with --recursive")
+	}
+
+	clients := newClientCache()
+	oldClient, err := clients.get(oldRef.Env)
+	if err != nil {
+		return false, err
+	}
+	newClient, err := clients.get(newRef.Env)
+	if err != nil {
+		return false, err
+	}
+
+	diffs := []vault.SecretDiff{}
+	var result interface{}
+	if recursive {
+		tree, err := vault.DiffTree(cmd.Context(), oldClient, oldRef.Path, newClient, newRef.Path, vault.WalkOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to compare secrets: %w", err)
+		}
+		for _, walkErr := range tree.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list %s\n", walkErr.Error())
+		}
+		if len(tree.Errors) > 0 {
+			return false, fmt.Errorf("%d folder(s) could not be listed", len(tree.Errors))
+		}
+		diffs, result = tree.Secrets, tree
+	} else {
+		oldSecret, err := readRef(oldClient, oldRef)
+		if err != nil {
+			return false, err
+		}
+		newSecret, err := readRef(newClient, newRef)
+		if err != nil {
+			return false, err
+		}
+		if oldSecret == nil && newSecret == nil {
+			return false, fmt.Errorf("neither %s nor %s exists", oldRef, newRef)
+		}
+		if diff := vault.DiffSecret(newRef.Path, oldSecret, newSecret); diff != nil {
+			diffs = []vault.SecretDiff{*diff}
+		}
+		result = diffs
+	}
+
+	if !showValues {
+		for i := range diffs {
+			for j := range diffs[i].Changes {
+				diffs[i].Changes[j].Old, diffs[i].Changes[j].New = nil, nil
+			}
+		}
+	}
+
+	header := []string{"Path", "Key", "Change"}
+	if showValues {
+		header = append(header, "Old", "New")
+	}
+	view := &output.Text{Rows: output.NewTable(header...)}
+	view.Linef("--- %s", displayRef(oldRef, oldClient))
+	view.Linef("+++ %s", displayRef(newRef, newClient))
+	for _, diff := range diffs {
+		if recursive {
+			view.Linef("%s %s", changeSymbol(diff.Type), diff.Path)
+		}
+		for _, change := range diff.Changes {
+			indent := ""
+			if recursive {
+				indent = "    "
+			}
+			view.Linef("%s%s %s%s", indent, changeSymbol(change.Type), change.Key, changeValues(change, showValues))
+
+			row := []string{diff.Path, change.Key, string(change.Type)}
+			if showValues {
+				row = append(row, plainValue(change.Old), plainValue(change.New))
+			}
+			view.Rows.Append(row...)
+		}
+	}
+	if err := out.Print(result, view); err != nil {
+		return false, err
+	}
+
+	return len(diffs) > 0, nil
+}
+
+// clientCache creates one Vault client per environment
+type clientCache map[string]*vault.Client
+
+func newClientCache() clientCache {
+	return make(clientCache)
+}
+
+// get returns the client for env, or for the selected environment if env is ""
+func (c clientCache) get(env string) (*vault.Client, error) {
+	if env == "" {
+		env = selectedEnvironment()
+	}
+	if client, ok := c[env]; ok {
+		return client, nil
+	}
+
+	client, err := vault.NewClient(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+	c[env] = client
+	return client, nil
+}
+
+// readRef reads the secret a reference points at, or nil if it doesn't exist
+func readRef(client *vault.Client, ref vault.SecretRef) (*vault.Secret, error) {
+	secret, err := client.GetSecretVersion(ref.Path, ref.Version)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	return secret, nil
+}
+
+// displayRef shows a reference with the environment it was resolved to
+func displayRef(ref vault.SecretRef, client *vault.Client) string {
+	ref.Env = client.EnvName
+	return ref.String()
+}
+
+func changeValues(change vault.KeyChange, show bool) string {
+	if !show {
+		return ""
+	}
+	switch change.Type {
+	case vault.ChangeAdded:
+		return ": " + formatValue(change.New)
+	case vault.ChangeRemoved:
+		return ": " + formatValue(change.Old)
+	default:
+		return ": " + formatValue(change.Old) + " -> " + formatValue(change.New)
+	}
+}
+
+// plainValue is formatValue with nothing for an unset value
+func plainValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return formatValue(v)
+}
+
+// formatValue prints strings as they are and anything else as JSON
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsDiffCmd)
+
+	secretsDiffCmd.Flags().BoolP("recursive", "r", false, "compare every secret below two folders")
+	secretsDiffCmd.Flags().Bool("show-values", false, "show the values that differ")
+}
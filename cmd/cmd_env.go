@@ -5,7 +5,8 @@ import (
 	"os"
 
 	"github.com/dautovri/ruslan-cli/pkg/config"
-	"github.com/olekukonko/tablewriter"
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -24,30 +25,62 @@ var envListCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Environment", "Current", "Cluster", "Region"})
-		table.SetBorder(false)
+		// An unknown --env/RUSLAN_ENV simply leaves nothing marked as current
+		selected, _, _ := cfg.ResolveEnvironment(selectedEnvironment())
 
-		for name, env := range cfg.Environments {
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		summaries := make([]envSummary, 0, len(cfg.Environments))
+		table := output.NewTable("Environment", "Current", "Cluster", "Region")
+		for _, name := range output.SortedKeys(cfg.Environments) {
+			env := cfg.Environments[name]
+			summaries = append(summaries, envSummary{
+				Name:        name,
+				Current:     name == selected,
+				ClusterName: env.ClusterName,
+				Region:      env.Region,
+			})
 			current := ""
-			if name == cfg.CurrentEnvironment {
+			if name == selected {
 				current = "✓"
 			}
-			table.Append([]string{name, current, env.ClusterName, env.Region})
+			table.Append(name, current, env.ClusterName, env.Region)
 		}
 
-		table.Render()
-		return nil
+		return out.Print(summaries, table)
 	},
 }
 
+// envSummary is an environment as shown by env list
+type envSummary struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	ClusterName string `json:"cluster_name"`
+	Region      string `json:"region"`
+}
+
+// envDetails is an environment as shown by env info
+type envDetails struct {
+	Name        string `json:"name"`
+	ProjectID   string `json:"project_id"`
+	ClusterName string `json:"cluster_name"`
+	Region      string `json:"region"`
+	Namespace   string `json:"namespace"`
+	VaultAddr   string `json:"vault_addr"`
+	Protected   bool   `json:"protected"`
+}
+
 var envUseCmd = &cobra.Command{
-	Use:   "use [environment]",
-	Short: "Switch to a different environment",
-	Args:  cobra.ExactArgs(1),
+	Use:         "use [environment]",
+	Short:       "Switch to a different environment",
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		envName := args[0]
-		
+
 		cfg, err := config.Load()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
@@ -57,27 +90,52 @@ var envUseCmd = &cobra.Command{
 			return fmt.Errorf("environment '%s' not found", envName)
 		}
 
-		cfg.CurrentEnvironment = envName
-		if err := cfg.Save(); err != nil {
+		if dryRun {
+			if err := printWithheld(cmd, envName, []vault.Request{}); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Would set current_environment to %s in %s\n", envName, config.ConfigPath())
+			return nil
+		}
+
+		// Only the user config is written; project settings stay in the repository
+		user, err := config.LoadUser()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		user.CurrentEnvironment = envName
+		if err := user.Save(); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
 
-		fmt.Printf("✓ Switched to environment: %s\n", envName)
+		fmt.Fprintf(os.Stderr, "✓ Switched to environment: %s\n", envName)
+		if project := cfg.ProjectFile(); project != "" && cfg.Origin("current_environment") == project {
+			fmt.Fprintf(os.Stderr, "Note: %s sets current_environment=%s, which takes precedence in this directory\n", project, cfg.CurrentEnvironment)
+		}
 		return nil
 	},
 }
 
 var envCurrentCmd = &cobra.Command{
 	Use:   "current",
-	Short: "Show current environment",
+	Short: "Show the environment this invocation would use",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load()
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		fmt.Println(cfg.CurrentEnvironment)
-		return nil
+		name, _, err := cfg.ResolveEnvironment(selectedEnvironment())
+		if err != nil {
+			return err
+		}
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+		return out.Print(map[string]string{"name": name}, output.Lines{name})
 	},
 }
 
@@ -90,19 +148,36 @@ var envInfoCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		env, exists := cfg.Environments[cfg.CurrentEnvironment]
-		if !exists {
-			return fmt.Errorf("current environment not found")
+		name, env, err := cfg.ResolveEnvironment(selectedEnvironment())
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("Environment: %s\n", cfg.CurrentEnvironment)
-		fmt.Printf("Project ID:  %s\n", env.ProjectID)
-		fmt.Printf("Cluster:     %s\n", env.ClusterName)
-		fmt.Printf("Region:      %s\n", env.Region)
-		fmt.Printf("Namespace:   %s\n", env.Namespace)
-		fmt.Printf("Vault Addr:  %s\n", env.VaultAddr)
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
 
-		return nil
+		var fields output.Fields
+		fields.Add("Environment", name)
+		fields.Add("Project ID", env.ProjectID)
+		fields.Add("Cluster", env.ClusterName)
+		fields.Add("Region", env.Region)
+		fields.Add("Namespace", env.Namespace)
+		fields.Add("Vault Addr", env.VaultAddr)
+		if env.Protected {
+			fields.Add("Protected", "yes")
+		}
+
+		return out.Print(envDetails{
+			Name:        name,
+			ProjectID:   env.ProjectID,
+			ClusterName: env.ClusterName,
+			Region:      env.Region,
+			Namespace:   env.Namespace,
+			VaultAddr:   env.VaultAddr,
+			Protected:   env.Protected,
+		}, &fields)
 	},
 }
 
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/execenv"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec --secret <path> [--secret <path>...] -- <command> [args...]",
+	Short: "Run a command with secrets as environment variables",
+	Long: `Read the given secrets and run a command with their keys as environment
+variables. Keys are uppercased (unless --uppercase=false), prefixed with
+--prefix, and characters that aren't valid in variable names become "_". Two
+keys that end up with the same name are an error.
+
+Signals are forwarded to the command, except Ctrl-C and others the terminal
+already delivers to it, and its exit code is returned. Values are only passed
+to the command's environment, never written to disk.`,
+	Example: `  ruslan-cli exec --secret secret/myapp/db --secret secret/myapp/api -- ./server
+  ruslan-cli exec --secret secret/myapp/db --prefix DB_ -- psql`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, _ := cmd.Flags().GetStringArray("secret")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		uppercase, _ := cmd.Flags().GetBool("uppercase")
+
+		if len(paths) == 0 {
+			return fmt.Errorf("at least one --secret is required")
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		sources := make([]execenv.Source, 0, len(paths))
+		for _, path := range paths {
+			secret, err := client.GetSecret(path)
+			if err != nil {
+				return fmt.Errorf("failed to read secret: %w", err)
+			}
+			sources = append(sources, execenv.Source{Path: path, Data: secret.Data})
+		}
+
+		vars, err := execenv.Map(sources, execenv.MapOptions{Prefix: prefix, Uppercase: uppercase})
+		if err != nil {
+			return err
+		}
+
+		env, replaced := execenv.Environ(os.Environ(), vars)
+		for _, name := range replaced {
+			fmt.Fprintf(os.Stderr, "Warning: %s from a secret replaces the inherited value\n", name)
+		}
+
+		code, err := execenv.Run(cmd.Context(), args[0], args[1:], env)
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return exitWith(cmd, code)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	// Everything after the command name belongs to the command
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().StringArrayP("secret", "s", nil, "secret to expose (repeatable)")
+	execCmd.Flags().String("prefix", "", "prefix for variable names")
+	execCmd.Flags().Bool("uppercase", true, "uppercase variable names")
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/generate"
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/spf13/cobra"
+)
+
+// errNothingToGenerate stops an update in which every key already exists
+var errNothingToGenerate = errors.New("nothing to generate")
+
+var secretsGenerateCmd = &cobra.Command{
+	Use:   "generate [path] [key=generator ...]",
+	Short: "Add random values to a secret",
+	Long: `Generate random values locally with crypto/rand and merge them into a secret,
+keeping all other keys. Generators:
+
+  password[:length[:symbols]]  letters and digits (default length 32)
+  hex[:length]                 hex characters (default length 64)
+  uuid                         a random UUID
+  ed25519                      an OpenSSH private key, with the public key
+                               stored under <key>` + generate.PublicKeySuffix + `
+
+Keys that already exist are kept unless --force is given; a key pair is only
+generated if neither of its keys exists. Generated values are only printed
+with --reveal; where mask_values is set, --reveal with structured output
+also needs --unmasked.`,
+	Example: `  ruslan-cli secrets generate secret/myapp/db password=password:32:symbols
+  ruslan-cli secrets generate secret/myapp/api token=hex:64 client_id=uuid
+  ruslan-cli secrets generate secret/myapp/deploy ssh_key=ed25519 --reveal`,
+	Args:        cobra.MinimumNArgs(2),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		force, _ := cmd.Flags().GetBool("force")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		specs := make(map[string]generate.Spec, len(args)-1)
+		keys := make([]string, 0, len(args)-1)
+		stored := make(map[string]bool)
+		for _, arg := range args[1:] {
+			key, value, ok := strings.Cut(arg, "=")
+			if !ok || key == "" {
+				return fmt.Errorf("invalid key=generator pair: %s", arg)
+			}
+			spec, err := generate.Parse(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			// A key pair also stores <key>_pub, which mustn't clash with another key
+			for _, k := range spec.Keys(key) {
+				if stored[k] {
+					return fmt.Errorf("key %s is given twice", k)
+				}
+				stored[k] = true
+			}
+			specs[key] = spec
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+		if reveal && out.Format.Structured() {
+			if err := requireUnmasked(cmd, client, "--format "+out.Format.Name); err != nil {
+				return err
+			}
+		}
+
+		if err := confirmProtected(cmd, client, "generate values in "+path); err != nil {
+			return err
+		}
+
+		var generated map[string]interface{}
+		var kept []string
+		version, err := client.UpdateSecret(path, func(data map[string]interface{}) error {
+			// Called again if someone else writes the secret meanwhile
+			generated = make(map[string]interface{})
+			kept = nil
+			for _, key := range keys {
+				// Nothing is overwritten without --force, including the public half of a key pair
+				var existing []string
+				for _, k := range specs[key].Keys(key) {
+					if _, exists := data[k]; exists {
+						existing = append(existing, k)
+					}
+				}
+				if len(existing) > 0 && !force {
+					kept = append(kept, existing...)
+					continue
+				}
+				values, err := specs[key].Values(key)
+				if err != nil {
+					return fmt.Errorf("failed to generate %s: %w", key, err)
+				}
+				for k, v := range values {
+					data[k] = v
+					generated[k] = v
+				}
+			}
+			if len(generated) == 0 {
+				return errNothingToGenerate
+			}
+			return nil
+		})
+
+		for _, key := range kept {
+			fmt.Fprintf(os.Stderr, "Kept existing key %s (use --force to replace it)\n", key)
+		}
+		if errors.Is(err, errNothingToGenerate) {
+			fmt.Fprintf(os.Stderr, "✓ %s already has all keys, nothing generated\n", path)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write secret: %w", err)
+		}
+
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
+
+		names := output.SortedKeys(generated)
+		if version > 0 {
+			fmt.Fprintf(os.Stderr, "✓ Generated %s in %s (version %d)\n", strings.Join(names, ", "), path, version)
+		} else {
+			fmt.Fprintf(os.Stderr, "✓ Generated %s in %s\n", strings.Join(names, ", "), path)
+		}
+
+		if !reveal {
+			return nil
+		}
+		revealer := newRevealer(cmd, client)
+		table := output.NewTable("Key", "Value")
+		for _, k := range names {
+			table.Append(k, revealer.Value(k, formatValue(generated[k])))
+		}
+		return out.Print(generated, table)
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsGenerateCmd)
+
+	secretsGenerateCmd.Flags().Bool("force", false, "replace keys that already exist")
+	secretsGenerateCmd.Flags().Bool("reveal", false, "print the generated values")
+	secretsGenerateCmd.Flags().Bool("unmasked", false, "allow --reveal with structured output where mask_values is set")
+}
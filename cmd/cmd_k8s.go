@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/k8s"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var secretsK8sManifestCmd = &cobra.Command{
+	Use:   "k8s-manifest [path...]",
+	Short: "Render secrets as a Kubernetes Secret manifest",
+	Long: `Read one or more secrets and render them as a single v1/Secret. The keys of
+all secrets are merged; a key found in two of them is an error. Values are
+base64-encoded under data, or written as they are under stringData with
+--string-data.
+
+The Secret is labelled with the environment and annotated with the source
+paths and versions, so it can be traced back to Vault. The output can be piped
+to kubectl or sealed offline, e.g. with kubeseal.`,
+	Example: `  ruslan-cli secrets k8s-manifest secret/myapp/db secret/myapp/api --name app-secrets --namespace app
+  ruslan-cli --env prod secrets k8s-manifest secret/myapp/db --name db | kubectl apply -f -
+  ruslan-cli secrets k8s-manifest secret/myapp/db --name db | kubeseal -o yaml > sealed-db.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		namespace, _ := cmd.Flags().GetString("namespace")
+		secretType, _ := cmd.Flags().GetString("type")
+		stringData, _ := cmd.Flags().GetBool("string-data")
+		output, _ := cmd.Flags().GetString("output")
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		sources := make([]k8s.Source, 0, len(args))
+		for _, path := range args {
+			secret, err := client.GetSecret(path)
+			if err != nil {
+				return fmt.Errorf("failed to read secret: %w", err)
+			}
+			sources = append(sources, k8s.Source{Path: path, Version: secret.Version, Data: secret.Data})
+		}
+
+		manifest, err := k8s.BuildSecret(k8s.Options{
+			Name:        name,
+			Namespace:   namespace,
+			Type:        secretType,
+			Environment: client.EnvName,
+			ClusterName: client.Env.ClusterName,
+			StringData:  stringData,
+		}, sources)
+		if err != nil {
+			return err
+		}
+
+		err = writeOutput(output, func(w io.Writer) error {
+			enc := yaml.NewEncoder(w)
+			enc.SetIndent(2)
+			if err := enc.Encode(manifest); err != nil {
+				return err
+			}
+			return enc.Close()
+		})
+		if err != nil {
+			return err
+		}
+		if output != "" {
+			fmt.Fprintf(os.Stderr, "✓ Wrote Secret %s (%d key(s)) to %s\n", name, len(manifest.Data)+len(manifest.StringData), output)
+		}
+		return nil
+	},
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsK8sManifestCmd)
+
+	secretsK8sManifestCmd.Flags().String("name", "", "name of the Secret")
+	secretsK8sManifestCmd.Flags().StringP("namespace", "n", "", "namespace of the Secret (default: kubectl's current namespace)")
+	secretsK8sManifestCmd.Flags().String("type", "Opaque", "type of the Secret")
+	secretsK8sManifestCmd.Flags().Bool("string-data", false, "write plain values to stringData instead of base64 in data")
+	secretsK8sManifestCmd.Flags().StringP("output", "o", "", "file to write (default: stdout)")
+	secretsK8sManifestCmd.MarkFlagRequired("name")
+}
@@ -2,24 +2,29 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"time"
 
-	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/dautovri/ruslan-cli/pkg/output"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	loginMethod   string
-	loginToken    string
-	loginRoleID   string
-	loginSecretID string
+	loginMethod    string
+	loginToken     string
+	loginRoleID    string
+	loginSecretID  string
+	loginSaveCreds bool
 )
 
 var loginCmd = &cobra.Command{
-	Use:   "login",
-	Short: "Authenticate to Vault",
-	Long:  `Authenticate to Vault using various methods (token, userpass, approle).`,
+	Use:         "login",
+	Short:       "Authenticate to Vault",
+	Long:        `Authenticate to Vault using various methods (token, userpass, approle).`,
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := vault.NewClient()
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
@@ -43,11 +48,19 @@ var loginCmd = &cobra.Command{
 			}
 			if password == "" {
 				fmt.Print("Password: ")
-				// In production, use terminal.ReadPassword for secure input
-				fmt.Scanln(&password)
+				if term.IsTerminal(int(os.Stdin.Fd())) {
+					raw, err := term.ReadPassword(int(os.Stdin.Fd()))
+					fmt.Println()
+					if err != nil {
+						return fmt.Errorf("failed to read password: %w", err)
+					}
+					password = string(raw)
+				} else {
+					fmt.Scanln(&password)
+				}
 			}
 
-			_, err := client.LoginWithUserPass(username, password)
+			_, err := client.LoginWithUserPass(username, password, loginSaveCreds)
 			if err != nil {
 				return fmt.Errorf("userpass authentication failed: %w", err)
 			}
@@ -56,7 +69,7 @@ var loginCmd = &cobra.Command{
 			if loginRoleID == "" || loginSecretID == "" {
 				return fmt.Errorf("role-id and secret-id are required for approle auth")
 			}
-			_, err := client.LoginWithAppRole(loginRoleID, loginSecretID)
+			_, err := client.LoginWithAppRole(loginRoleID, loginSecretID, loginSaveCreds)
 			if err != nil {
 				return fmt.Errorf("approle authentication failed: %w", err)
 			}
@@ -65,16 +78,25 @@ var loginCmd = &cobra.Command{
 			return fmt.Errorf("unsupported auth method: %s", loginMethod)
 		}
 
-		fmt.Println("✓ Successfully authenticated to Vault")
+		if client.DryRun() {
+			if err := printDryRun(cmd, client); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Would save the token for %s\n", client.Env.VaultAddr)
+			return nil
+		}
+
+		fmt.Fprintln(os.Stderr, "✓ Successfully authenticated to Vault")
 		return nil
 	},
 }
 
 var logoutCmd = &cobra.Command{
-	Use:   "logout",
-	Short: "Remove saved authentication",
+	Use:         "logout",
+	Short:       "Remove saved authentication",
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := vault.NewClient()
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
@@ -82,8 +104,15 @@ var logoutCmd = &cobra.Command{
 		if err := client.Logout(); err != nil {
 			return fmt.Errorf("logout failed: %w", err)
 		}
+		if client.DryRun() {
+			if err := printDryRun(cmd, client); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Would remove the saved token for %s\n", client.Env.VaultAddr)
+			return nil
+		}
 
-		fmt.Println("✓ Logged out successfully")
+		fmt.Fprintln(os.Stderr, "✓ Logged out successfully")
 		return nil
 	},
 }
@@ -92,34 +121,77 @@ var authStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show authentication status",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := vault.NewClient()
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		status := authStatus{Environment: client.EnvName}
+		var fields output.Fields
+
 		tokenInfo, err := client.GetTokenInfo()
 		if err != nil {
-			fmt.Println("Not authenticated")
-			return nil
+			fields.Add("Authenticated", "No")
+			return out.Print(status, &fields)
 		}
 
-		fmt.Printf("Authenticated: Yes\n")
+		status.Authenticated = true
+		fields.Add("Authenticated", "Yes")
 		if tokenInfo != nil && tokenInfo.Data != nil {
 			if accessor, ok := tokenInfo.Data["accessor"].(string); ok {
-				fmt.Printf("Token Accessor: %s\n", accessor)
+				status.Accessor = accessor
+				fields.Add("Token Accessor", accessor)
 			}
 			if policies, ok := tokenInfo.Data["policies"].([]interface{}); ok {
-				fmt.Printf("Policies: %v\n", policies)
+				for _, p := range policies {
+					status.Policies = append(status.Policies, fmt.Sprint(p))
+				}
+				fields.Add("Policies", fmt.Sprint(status.Policies))
 			}
-			if ttl, ok := tokenInfo.Data["ttl"].(float64); ok {
-				fmt.Printf("TTL: %.0fs\n", ttl)
+			if ttl, err := tokenInfo.TokenTTL(); err == nil {
+				status.TTL = ttl.String()
+				fields.Add("TTL", status.TTL)
 			}
 		}
 
-		return nil
+		if entry := client.TokenEntry(); entry != nil {
+			status.Method = entry.Method
+			status.AutoRefresh = client.Config.AutoRefresh
+			status.SavedCredentials = len(entry.Credentials) > 0
+			if entry.Method != "" {
+				fields.Add("Method", entry.Method)
+			}
+			if !entry.ExpiresAt.IsZero() {
+				expires := entry.ExpiresAt.Local()
+				status.ExpiresAt = &expires
+				remaining := time.Until(entry.ExpiresAt).Round(time.Second)
+				fields.Add("Expires", fmt.Sprintf("%s (in %s)", expires.Format(time.RFC3339), remaining))
+			}
+			fields.Add("Auto Refresh", fmt.Sprintf("%t (saved credentials: %t)", status.AutoRefresh, status.SavedCredentials))
+		}
+
+		return out.Print(status, &fields)
 	},
 }
 
+// authStatus is the login state shown by auth status
+type authStatus struct {
+	Environment      string     `json:"environment"`
+	Authenticated    bool       `json:"authenticated"`
+	Accessor         string     `json:"accessor,omitempty"`
+	Policies         []string   `json:"policies,omitempty"`
+	TTL              string     `json:"ttl,omitempty"`
+	Method           string     `json:"method,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	AutoRefresh      bool       `json:"auto_refresh"`
+	SavedCredentials bool       `json:"saved_credentials"`
+}
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(logoutCmd)
@@ -138,4 +210,5 @@ func init() {
 	loginCmd.Flags().StringVar(&loginSecretID, "secret-id", "", "approle secret ID")
 	loginCmd.Flags().String("username", "", "username for userpass auth")
 	loginCmd.Flags().String("password", "", "password for userpass auth")
+	loginCmd.Flags().BoolVar(&loginSaveCreds, "save-credentials", false, "keep approle/userpass credentials in the token store so auto refresh can log in again (requires encrypted-file or command token store)")
 }
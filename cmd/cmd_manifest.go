@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/manifest"
+	"github.com/dautovri/ruslan-cli/pkg/sealbox"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// manifestPlan is the machine-readable plan of all planned environments
+type manifestPlan struct {
+	Environments []*manifest.EnvironmentPlan `json:"environments" yaml:"environments"`
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes needed to match a secrets manifest",
+	Long: `Compare Vault with a secrets manifest and show which secrets would be created
+or updated, key by key and without values. Every environment in the manifest
+is planned unless one is selected with --env or RUSLAN_ENV.
+
+Use --out to save the plan as JSON for review, and --detailed-exitcode to exit
+with status 2 when there are changes.`,
+	Example: `  ruslan-cli plan -f secrets.yaml
+  ruslan-cli plan -f secrets.yaml --env prod --out plan.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		detailed, _ := cmd.Flags().GetBool("detailed-exitcode")
+
+		plan, _, err := planManifest(cmd)
+		if err != nil {
+			return err
+		}
+
+		if out != "" {
+			err := writeOutput(out, func(w io.Writer) error {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(plan)
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "✓ Plan saved to %s\n", out)
+		}
+
+		if detailed && pendingWrites(plan) > 0 {
+			return exitWith(cmd, 2)
+		}
+		return nil
+	},
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Write the changes needed to match a secrets manifest",
+	Long: `Plan like "ruslan-cli plan" and write the changes. Protected environments
+have to be confirmed by typing their name, or with --yes --confirm-env <name>;
+an environment that isn't confirmed is skipped. Writes use check-and-set, so
+secrets changed after the plan was made are not overwritten.`,
+	Example:     `  ruslan-cli apply -f secrets.yaml --env dev`,
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, clients, err := planManifest(cmd)
+		if err != nil {
+			return err
+		}
+
+		failed, total, skipped := 0, 0, 0
+		for _, envPlan := range plan.Environments {
+			pending := envPlan.Pending()
+			if len(pending) == 0 {
+				continue
+			}
+
+			client := clients[envPlan.Environment]
+			if err := confirmProtected(cmd, client, fmt.Sprintf("write %d secret(s)", len(pending))); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ Skipped %s: %v\n", client.EnvName, err)
+				skipped++
+				continue
+			}
+
+			total += len(pending)
+			for i, err := range client.ApplyWrites(cmd.Context(), pending, vault.DefaultWalkConcurrency) {
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "✗ %s: %s: %v\n", client.EnvName, pending[i].Path, err)
+					failed++
+					continue
+				}
+				if !client.DryRun() {
+					fmt.Fprintf(os.Stderr, "✓ %s: wrote %s\n", client.EnvName, pending[i].Path)
+				}
+			}
+			if client.DryRun() {
+				reportDryRun(client)
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d secret(s) could not be written", failed, total)
+		}
+		if skipped > 0 {
+			return fmt.Errorf("%d environment(s) were not confirmed and skipped", skipped)
+		}
+		return nil
+	},
+}
+
+// planManifest loads the manifest given with --file and plans the selected
+// environments, printing the plan. It returns the clients it used by name.
+func planManifest(cmd *cobra.Command) (*manifestPlan, map[string]*vault.Client, error) {
+	file, _ := cmd.Flags().GetString("file")
+	prune, _ := cmd.Flags().GetBool("prune")
+
+	out, err := newPrinter(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, err := manifest.Load(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envs := m.EnvironmentNames()
+	if selected := selectedEnvironment(); selected != "" {
+		envs = []string{selected}
+	}
+
+	resolver := manifest.NewResolver(m, manifestPassphrase)
+	plan := &manifestPlan{}
+	clients := make(map[string]*vault.Client, len(envs))
+	for _, env := range envs {
+		client, err := newEnvClient(env)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Vault client for %s: %w", env, err)
+		}
+		clients[env] = client
+
+		envPlan, err := resolver.Plan(cmd.Context(), client, env, prune)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to plan %s: %w", env, err)
+		}
+		plan.Environments = append(plan.Environments, envPlan)
+	}
+
+	view := writesView("Environment")
+	for _, envPlan := range plan.Environments {
+		counts := make(map[string]int)
+		for _, w := range envPlan.Writes {
+			counts[w.Action]++
+		}
+		view.Linef("Environment %s:", envPlan.Environment)
+		appendWrites(view, envPlan.Writes, envPlan.Environment)
+		view.Linef("Plan: %d to create, %d to update, %d unchanged", counts[vault.ActionCreate], counts[vault.ActionUpdate], counts[vault.ActionUnchanged])
+		view.Linef("")
+	}
+	return plan, clients, out.Print(plan, view)
+}
+
+func pendingWrites(plan *manifestPlan) int {
+	n := 0
+	for _, envPlan := range plan.Environments {
+		n += len(envPlan.Pending())
+	}
+	return n
+}
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Manage encrypted value files for secrets manifests",
+	Long: `Value files hold the key/value pairs a secrets manifest refers to, as YAML
+sealed with a passphrase from ` + manifest.PassphraseEnvVar + ` or the terminal.`,
+}
+
+var manifestSealCmd = &cobra.Command{
+	Use:   "seal [file]",
+	Short: "Encrypt a YAML file of values",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		if out == "" {
+			out = args[0] + ".enc"
+		}
+
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("%s is not a YAML map of values: %w", args[0], err)
+		}
+
+		pass, err := manifestPassphrase()
+		if err != nil {
+			return err
+		}
+		sealed, err := sealbox.Seal(pass, data)
+		if err != nil {
+			return err
+		}
+
+		err = writeOutput(out, func(w io.Writer) error {
+			_, err := w.Write(sealed)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✓ Sealed %d value(s) to %s\n", len(values), out)
+		return nil
+	},
+}
+
+var manifestUnsealCmd = &cobra.Command{
+	Use:   "unseal [file]",
+	Short: "Print the values of an encrypted file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sealed, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		pass, err := manifestPassphrase()
+		if err != nil {
+			return err
+		}
+		data, err := sealbox.Open(pass, sealed)
+		if err != nil {
+			return err
+		}
+
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+// manifestPassphrase reads the value file passphrase from the environment or
+// the terminal
+func manifestPassphrase() (string, error) {
+	if pass := os.Getenv(manifest.PassphraseEnvVar); pass != "" {
+		return pass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("manifest passphrase required: set %s", manifest.PassphraseEnvVar)
+	}
+
+	fmt.Fprint(os.Stderr, "Manifest passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if len(pass) == 0 {
+		return "", errors.New("manifest passphrase is empty")
+	}
+	return string(pass), nil
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(manifestCmd)
+	manifestCmd.AddCommand(manifestSealCmd)
+	manifestCmd.AddCommand(manifestUnsealCmd)
+
+	for _, c := range []*cobra.Command{planCmd, applyCmd} {
+		c.Flags().StringP("file", "f", "", "secrets manifest")
+		c.Flags().Bool("prune", false, "remove keys the manifest doesn't list from every managed secret")
+		c.MarkFlagRequired("file")
+	}
+	planCmd.Flags().String("out", "", "save the plan as JSON")
+	planCmd.Flags().Bool("detailed-exitcode", false, "exit with status 2 when there are changes")
+
+	manifestSealCmd.Flags().StringP("out", "o", "", "encrypted file to write (default: <file>.enc)")
+}
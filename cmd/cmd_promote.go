@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+var secretsPromoteCmd = &cobra.Command{
+	Use:   "promote [path]",
+	Short: "Copy a secret from one environment to another",
+	Long: `Copy a secret to the same path in another environment, using each
+environment's own saved token. The key-level changes are shown first; writing
+to a protected environment has to be confirmed by typing its name, or with
+--yes --confirm-env <name>.
+
+With --recursive every secret below the path is promoted. Secrets changed in
+the target after the comparison are not overwritten.`,
+	Example: `  ruslan-cli secrets promote secret/myapp/config --from dev --to prod
+  ruslan-cli secrets promote secret/myapp -r --from dev --to prod`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		if from == "" {
+			from = selectedEnvironment()
+		}
+
+		src, err := newEnvClient(from)
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client for source: %w", err)
+		}
+		dst, err := newEnvClient(to)
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client for target: %w", err)
+		}
+		if src.EnvName == dst.EnvName {
+			return fmt.Errorf("source and target are both %s", src.EnvName)
+		}
+
+		plan, err := vault.PlanPromotion(cmd.Context(), src, dst, args[0], recursive, vault.WalkOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to compare secrets: %w", err)
+		}
+
+		view := writesView()
+		view.Linef("Promoting from %s to %s:", plan.From, plan.To)
+		appendWrites(view, plan.Promotions)
+		if err := out.Print(plan, view); err != nil {
+			return err
+		}
+		for _, walkErr := range plan.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list %s\n", walkErr.Error())
+		}
+
+		pending := plan.Pending()
+		if len(pending) == 0 {
+			fmt.Fprintf(os.Stderr, "Nothing to promote: %s is up to date\n", dst.EnvName)
+			return promotionErrors(plan)
+		}
+
+		if err := confirmProtected(cmd, dst, fmt.Sprintf("promote %d secret(s)", len(pending))); err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, promotion := range pending {
+			if _, err := dst.ApplyWrite(promotion); err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", promotion.Path, err)
+				failed++
+				continue
+			}
+			if !dst.DryRun() {
+				fmt.Fprintf(os.Stderr, "✓ Promoted %s to %s\n", promotion.Path, dst.EnvName)
+			}
+		}
+		if dst.DryRun() {
+			reportDryRun(dst)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d secret(s) could not be promoted", failed, len(pending))
+		}
+		return promotionErrors(plan)
+	},
+}
+
+// writesView returns an empty view for planned writes; plain rows are
+// "path action key change", after the columns named in lead
+func writesView(lead ...string) *output.Text {
+	header := append(append([]string{}, lead...), "Path", "Action", "Key", "Change")
+	return &output.Text{Rows: output.NewTable(header...)}
+}
+
+// appendWrites adds the key-level changes of planned writes, without values,
+// to view. Each plain row starts with the cells in lead.
+func appendWrites(view *output.Text, writes []*vault.PlannedWrite, lead ...string) {
+	row := func(cells ...string) {
+		view.Rows.Append(append(append([]string{}, lead...), cells...)...)
+	}
+
+	for _, w := range writes {
+		switch {
+		case w.Create:
+			view.Linef("+ %s (new)", w.Path)
+		case w.Unchanged():
+			view.Linef("  %s (unchanged)", w.Path)
+			row(w.Path, w.Action, "", "")
+			continue
+		default:
+			view.Linef("~ %s", w.Path)
+		}
+
+		for _, change := range w.Changes {
+			view.Linef("    %s %s", changeSymbol(change.Type), change.Key)
+			row(w.Path, w.Action, change.Key, string(change.Type))
+		}
+	}
+}
+
+func changeSymbol(t vault.ChangeType) string {
+	switch t {
+	case vault.ChangeAdded:
+		return "+"
+	case vault.ChangeRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+func promotionErrors(plan *vault.PromotionPlan) error {
+	if len(plan.Errors) > 0 {
+		return fmt.Errorf("%d folder(s) could not be listed", len(plan.Errors))
+	}
+	return nil
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsPromoteCmd)
+
+	secretsPromoteCmd.Flags().String("from", "", "environment to copy from (default: the selected environment)")
+	secretsPromoteCmd.Flags().String("to", "", "environment to copy to")
+	secretsPromoteCmd.Flags().BoolP("recursive", "r", false, "promote every secret below the path")
+	secretsPromoteCmd.MarkFlagRequired("to")
+}
@@ -4,12 +4,17 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/confirm"
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile string
+	dryRun  bool
 	Version string
 	Commit  string
 	Date    string
@@ -25,6 +30,49 @@ deployed in GCP/GKE across multiple environments (dev/prod).
 It provides easy switching between environments, authentication management,
 and common secret operations.`,
 	Version: Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if dryRun && cmd.Annotations[dryRunAnnotation] == "" {
+			return fmt.Errorf("%s does not support --dry-run", cmd.CommandPath())
+		}
+		return nil
+	},
+}
+
+// dryRunAnnotation marks commands that honor --dry-run
+const dryRunAnnotation = "dry-run"
+
+// supportsDryRun is the Annotations value for commands that honor --dry-run
+var supportsDryRun = map[string]string{dryRunAnnotation: "true"}
+
+// ExitError ends the program with Code, for commands whose exit status is
+// part of their result. Err, if set, is reported before exiting.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitWith returns an ExitError for cmd, keeping cobra from reporting it
+func exitWith(cmd *cobra.Command, code int) error {
+	return failWith(cmd, code, nil)
+}
+
+// failWith returns an ExitError reporting err with a status other than the
+// usual 1, for commands that use 1 as a result
+func failWith(cmd *cobra.Command, code int, err error) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return &ExitError{Code: code, Err: err}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -38,10 +86,14 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.ruslan-cli/config.yaml)")
 	rootCmd.PersistentFlags().String("env", "", "environment to use (dev/prod)")
-	rootCmd.PersistentFlags().String("format", "table", "output format (table, json, yaml)")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "don't ask for confirmation; protected environments also need --confirm-env")
+	rootCmd.PersistentFlags().StringSlice("confirm-env", nil, "protected environments that may be changed without a prompt when --yes is given")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show the requests that would change Vault or the config without sending or saving them")
+	rootCmd.PersistentFlags().String("format", "table", "output format: table, json, yaml, plain or go-template=<template> (overrides output_format from the config)")
 
 	viper.BindPFlag("environment", rootCmd.PersistentFlags().Lookup("env"))
 	viper.BindPFlag("format", rootCmd.PersistentFlags().Lookup("format"))
+	viper.BindEnv("environment", config.EnvVar)
 
 	// Version template
 	rootCmd.SetVersionTemplate(`ruslan-cli {{.Version}}
@@ -52,6 +104,7 @@ Built: ` + Date + `
 
 func initConfig() {
 	if cfgFile != "" {
+		config.SetPath(cfgFile)
 		viper.SetConfigFile(cfgFile)
 	} else {
 		home, err := os.UserHomeDir()
@@ -73,3 +126,91 @@ func initConfig() {
 		// Config loaded successfully
 	}
 }
+
+// selectedEnvironment returns the environment requested for this invocation
+// via --env or RUSLAN_ENV, or "" to use the saved current environment
+func selectedEnvironment() string {
+	return viper.GetString("environment")
+}
+
+// confirmProtected asks before action changes a protected environment: its
+// name has to be typed to go ahead, on the terminal even when stdin is piped.
+// Without a terminal the change is refused unless --yes and --confirm-env
+// <name> are both given.
+func confirmProtected(cmd *cobra.Command, client *vault.Client, action string) error {
+	if !client.Env.Protected || client.DryRun() {
+		return nil
+	}
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	confirmed, _ := cmd.Flags().GetStringSlice("confirm-env")
+	gate := confirm.Gate{Yes: yes, ConfirmEnv: confirmed, Prompt: os.Stderr}
+	if tty, err := confirm.OpenTerminal(); err == nil {
+		defer tty.Close()
+		gate.Terminal = tty
+	}
+	return gate.Check(client.EnvName, action)
+}
+
+// newPrinter returns a printer to stdout in the format given with --format,
+// falling back to output_format from the config
+func newPrinter(cmd *cobra.Command) (*output.Printer, error) {
+	name, _ := cmd.Flags().GetString("format")
+	if !cmd.Flags().Changed("format") {
+		if cfg, err := config.Load(); err == nil && cfg.OutputFormat != "" {
+			name = cfg.OutputFormat
+		}
+	}
+
+	format, err := output.ParseFormat(name)
+	if err != nil {
+		return nil, err
+	}
+	return output.NewPrinter(os.Stdout, format), nil
+}
+
+// newVaultClient creates a Vault client for the selected environment. With
+// --dry-run the client only records what it would change.
+func newVaultClient() (*vault.Client, error) {
+	return newEnvClient(selectedEnvironment())
+}
+
+// newEnvClient creates a Vault client for env, honoring --dry-run
+func newEnvClient(env string) (*vault.Client, error) {
+	if dryRun {
+		return vault.NewDryRunClient(env)
+	}
+	return vault.NewClient(env)
+}
+
+// printDryRun shows the requests a dry run held back, in place of the
+// command's usual result
+func printDryRun(cmd *cobra.Command, client *vault.Client) error {
+	return printWithheld(cmd, client.EnvName, client.Recorder().Withheld())
+}
+
+// printWithheld shows requests a dry run held back from env, for commands
+// without a client of their own
+func printWithheld(cmd *cobra.Command, env string, requests []vault.Request) error {
+	out, err := newPrinter(cmd)
+	if err != nil {
+		return err
+	}
+
+	lines := make(output.Lines, len(requests))
+	for i, r := range requests {
+		lines[i] = r.String()
+	}
+
+	fmt.Fprintf(os.Stderr, "Dry run: nothing was sent to %s. Would send:\n", env)
+	return out.Print(requests, lines)
+}
+
+// reportDryRun lists the requests a dry run held back on stderr, for commands
+// whose result is the plan they printed before writing
+func reportDryRun(client *vault.Client) {
+	fmt.Fprintf(os.Stderr, "Dry run: nothing was sent to %s. Would send:\n", client.EnvName)
+	for _, r := range client.Recorder().Withheld() {
+		fmt.Fprintln(os.Stderr, r)
+	}
+}
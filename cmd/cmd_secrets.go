@@ -1,21 +1,27 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/output"
+	"github.com/dautovri/ruslan-cli/pkg/query"
+	"github.com/dautovri/ruslan-cli/pkg/values"
 	"github.com/dautovri/ruslan-cli/pkg/vault"
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var secretsCmd = &cobra.Command{
 	Use:   "secrets",
 	Short: "Manage secrets in Vault",
-	Long:  `Commands for reading, writing, and managing secrets in Vault KV v2 engine.`,
+	Long: `Commands for reading, writing, and managing secrets in Vault KV engines.
+The mount and KV version (v1 or v2) of a path are detected automatically.`,
 }
 
 var secretsListCmd = &cobra.Command{
@@ -24,40 +30,142 @@ var secretsListCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
-		
-		client, err := vault.NewClient()
+		recursive, _ := cmd.Flags().GetBool("recursive")
+
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
+		if recursive {
+			return walkSecrets(cmd, client, path, false)
+		}
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
 		secrets, err := client.ListSecrets(path)
 		if err != nil {
 			return fmt.Errorf("failed to list secrets: %w", err)
 		}
 
-		for _, secret := range secrets {
-			fmt.Println(secret)
+		return out.Print(secrets, output.Lines(secrets))
+	},
+}
+
+var secretsTreeCmd = &cobra.Command{
+	Use:   "tree [path]",
+	Short: "Show all secrets below a path as a tree",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
-		return nil
+		return walkSecrets(cmd, client, args[0], true)
 	},
 }
 
+// walkSecrets lists path recursively and prints it as a tree or a flat list
+// of secret paths. Folders that can't be listed are reported at the end and
+// make the command fail, but don't stop the walk.
+func walkSecrets(cmd *cobra.Command, client *vault.Client, path string, tree bool) error {
+	depth, _ := cmd.Flags().GetInt("depth")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+	out, err := newPrinter(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Walk(cmd.Context(), path, vault.WalkOptions{
+		MaxDepth:    depth,
+		Concurrency: concurrency,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	view := output.Lines(result.Secrets())
+	if tree {
+		view = output.Lines{result.Root}
+		for _, e := range result.Entries {
+			view = append(view, strings.Repeat("  ", e.Depth)+e.Name())
+		}
+	}
+	if err := out.Print(result, view); err != nil {
+		return err
+	}
+
+	for _, walkErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list %s\n", walkErr.Error())
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d folder(s) could not be listed", len(result.Errors))
+	}
+	return nil
+}
+
 var secretsGetCmd = &cobra.Command{
 	Use:   "get [path]",
 	Short: "Read a secret",
-	Args:  cobra.ExactArgs(1),
+	Long: `Read a secret and print its keys and values.
+
+Values are masked in table and plain output, showing their length and a
+fingerprint keyed to this machine (~/.ruslan-cli/mask_key), so they can be
+compared without being shown; use --reveal or --reveal-keys to show them. Environments with
+mask_values set always mask them. --field, --query and --format json, yaml or
+go-template print values as they are, so in those environments they are
+refused unless --unmasked is given.
+
+--field prints a single value. --query selects values with a JSONPath-style
+expression over the secret's data and metadata, e.g. ".data.tls.cert",
+".data.hosts[*]" or ".data.user,.metadata.version". Keys inside values that
+hold JSON can be selected too. Strings are printed as they are and other
+values as JSON, one per line.`,
+	Example: `  ruslan-cli secrets get secret/myapp/db --field password
+  ruslan-cli secrets get secret/myapp/db --query '.data.user,.metadata.created_time'
+  ruslan-cli secrets get secret/myapp/config --query '.data.settings.db.port'`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
-		format, _ := cmd.Flags().GetString("format")
 		field, _ := cmd.Flags().GetString("field")
-		
-		client, err := vault.NewClient()
+		version, _ := cmd.Flags().GetInt("version")
+		expr, _ := cmd.Flags().GetString("query")
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		var q *query.Query
+		if expr != "" {
+			if q, err = query.Parse(expr); err != nil {
+				return err
+			}
+		}
+
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
-		secret, err := client.GetSecret(path)
+		switch {
+		case field != "":
+			err = requireUnmasked(cmd, client, "--field")
+		case q != nil:
+			err = requireUnmasked(cmd, client, "--query")
+		case out.Format.Structured():
+			err = requireUnmasked(cmd, client, "--format "+out.Format.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		secret, err := client.GetSecretVersion(path, version)
 		if err != nil {
 			return fmt.Errorf("failed to get secret: %w", err)
 		}
@@ -65,76 +173,219 @@ var secretsGetCmd = &cobra.Command{
 		// If specific field requested
 		if field != "" {
 			if val, ok := secret.Data[field]; ok {
+				// Binary values are written back exactly as they were read
+				if b, ok := values.Binary(val); ok {
+					_, err := os.Stdout.Write(b)
+					return err
+				}
 				fmt.Println(val)
 				return nil
 			}
 			return fmt.Errorf("field '%s' not found", field)
 		}
 
-		// Output full secret in requested format
-		switch format {
-		case "json":
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "  ")
-			return enc.Encode(secret.Data)
-		case "yaml":
-			enc := yaml.NewEncoder(os.Stdout)
-			return enc.Encode(secret.Data)
-		default: // table
-			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"Key", "Value"})
-			table.SetBorder(false)
-			for k, v := range secret.Data {
-				table.Append([]string{k, fmt.Sprintf("%v", v)})
-			}
-			table.Render()
+		if q != nil {
+			return printQuery(out, q, secret)
 		}
 
-		return nil
+		revealer := newRevealer(cmd, client)
+		table := output.NewTable("Key", "Value")
+		for _, k := range output.SortedKeys(secret.Data) {
+			v := secret.Data[k]
+			if b, ok := values.Binary(v); ok {
+				table.Append(k, fmt.Sprintf("<binary, %d bytes; use --field %s>", len(b), k))
+				continue
+			}
+			table.Append(k, revealer.Value(k, formatValue(v)))
+		}
+		return out.Print(secret.Data, table)
 	},
 }
 
 var secretsPutCmd = &cobra.Command{
-	Use:   "put [path] [key=value ...]",
+	Use:   "put [path] [key=value ...] [--file file]",
 	Short: "Write a secret",
-	Args:  cobra.MinimumNArgs(2),
+	Long: `Write a secret, replacing all its keys.
+
+Values given as key=@file are read from a file and key=- from stdin, which
+keeps them out of shell history and the process list; use key=@@value for a
+literal value starting with "@". Binary content is stored base64-encoded
+behind a "` + values.BinaryPrefix + `" marker, which "secrets get --field" decodes.
+
+--file reads keys from a JSON, YAML or dotenv file ("-" for stdin). Pairs given
+as arguments are merged over the file's keys, so a file of defaults can be
+adjusted on the command line. Stdin can only be read once.`,
+	Example: `  ruslan-cli secrets put secret/myapp/tls cert=@tls.crt key=@tls.key
+  vault-password-helper | ruslan-cli secrets put secret/myapp/db password=-
+  ruslan-cli secrets put secret/myapp/config --file config.env debug=false`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
 		dataFile, _ := cmd.Flags().GetString("file")
-		
-		client, err := vault.NewClient()
+		inputFormat, _ := cmd.Flags().GetString("input-format")
+
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
-		var data map[string]interface{}
+		// Confirm first so that a value typed for key=- isn't taken as the answer
+		if err := confirmProtected(cmd, client, "write "+path); err != nil {
+			return err
+		}
 
-		// Load from file if specified
-		if dataFile != "" {
-			fileData, err := os.ReadFile(dataFile)
+		input := values.Input{File: dataFile, FileFormat: inputFormat, Args: args[1:]}
+		data, err := input.Data(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("cas") {
+			cas, _ := cmd.Flags().GetInt("cas")
+			version, err := client.PutSecretCAS(path, data, cas)
 			if err != nil {
-				return fmt.Errorf("failed to read file: %w", err)
-			}
-			if err := json.Unmarshal(fileData, &data); err != nil {
-				return fmt.Errorf("failed to parse JSON: %w", err)
+				return fmt.Errorf("failed to write secret: %w", err)
 			}
-		} else {
-			// Parse key=value pairs
-			data = make(map[string]interface{})
-			for _, arg := range args[1:] {
-				parts := strings.SplitN(arg, "=", 2)
-				if len(parts) != 2 {
-					return fmt.Errorf("invalid key=value pair: %s", arg)
-				}
-				data[parts[0]] = parts[1]
+			if client.DryRun() {
+				return printDryRun(cmd, client)
 			}
+			fmt.Fprintf(os.Stderr, "✓ Secret written to %s (version %d)\n", path, version)
+			return nil
 		}
 
 		if err := client.PutSecret(path, data); err != nil {
 			return fmt.Errorf("failed to write secret: %w", err)
 		}
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
 
-		fmt.Printf("✓ Secret written to %s\n", path)
+		fmt.Fprintf(os.Stderr, "✓ Secret written to %s\n", path)
+		return nil
+	},
+}
+
+var secretsPatchCmd = &cobra.Command{
+	Use:   "patch [path] [key=value ...]",
+	Short: "Update individual keys of a secret",
+	Long: `Set or remove individual keys of a secret, keeping all other keys.
+
+On KV v2 the PATCH method is used when Vault supports it; otherwise the secret
+is read, merged and written back with check-and-set, retrying if someone else
+writes it at the same time.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		unset, _ := cmd.Flags().GetStringSlice("unset")
+
+		if len(args) == 1 && len(unset) == 0 {
+			return fmt.Errorf("nothing to patch: give key=value pairs or --unset")
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		// Confirm first so that a value typed for key=- isn't taken as the answer
+		if err := confirmProtected(cmd, client, "patch "+path); err != nil {
+			return err
+		}
+
+		set, err := parseKeyValues(args[1:])
+		if err != nil {
+			return err
+		}
+
+		version, err := client.PatchSecret(path, set, unset)
+		if err != nil {
+			return fmt.Errorf("failed to patch secret: %w", err)
+		}
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
+
+		if version > 0 {
+			fmt.Fprintf(os.Stderr, "✓ Secret patched: %s (version %d)\n", path, version)
+		} else {
+			fmt.Fprintf(os.Stderr, "✓ Secret patched: %s\n", path)
+		}
+		return nil
+	},
+}
+
+var secretsVersionsCmd = &cobra.Command{
+	Use:   "versions [path]",
+	Short: "Show the version history of a KV v2 secret",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		meta, err := client.ReadMetadata(path)
+		if err != nil {
+			return fmt.Errorf("failed to read versions: %w", err)
+		}
+
+		table := output.NewTable("Version", "Current", "Created", "Deleted", "Destroyed")
+		for _, v := range meta.Versions {
+			current, deleted, destroyed := "", "", ""
+			if v.Version == meta.CurrentVersion {
+				current = "✓"
+			}
+			if v.Deleted() {
+				deleted = v.DeletionTime.Local().Format(time.RFC3339)
+			}
+			if v.Destroyed {
+				destroyed = "yes"
+			}
+			table.Append(strconv.Itoa(v.Version), current, v.CreatedTime.Local().Format(time.RFC3339), deleted, destroyed)
+		}
+		return out.Print(meta.Versions, table)
+	},
+}
+
+var secretsRollbackCmd = &cobra.Command{
+	Use:         "rollback [path]",
+	Short:       "Restore an older version of a KV v2 secret as the current version",
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		to, _ := cmd.Flags().GetInt("to")
+		if to <= 0 {
+			return fmt.Errorf("--to must be a version number")
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		if err := confirmProtected(cmd, client, fmt.Sprintf("roll back %s to version %d", path, to)); err != nil {
+			return err
+		}
+
+		version, err := client.RollbackSecret(path, to)
+		if err != nil {
+			return fmt.Errorf("failed to roll back secret: %w", err)
+		}
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
+
+		fmt.Fprintf(os.Stderr, "✓ Rolled back %s to version %d (now version %d)\n", path, to, version)
 		return nil
 	},
 }
@@ -142,20 +393,120 @@ var secretsPutCmd = &cobra.Command{
 var secretsDeleteCmd = &cobra.Command{
 	Use:   "delete [path]",
 	Short: "Delete a secret",
-	Args:  cobra.ExactArgs(1),
+	Long: `Delete a secret.
+
+On KV v2 this soft-deletes the latest version, which can be restored with
+'secrets undelete'. Use --versions to soft-delete specific versions, or
+--all-versions to permanently remove the secret with all versions and metadata.`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		versions, _ := cmd.Flags().GetIntSlice("versions")
+		allVersions, _ := cmd.Flags().GetBool("all-versions")
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		if err := confirmProtected(cmd, client, "delete "+path); err != nil {
+			return err
+		}
+
+		switch {
+		case allVersions:
+			if err := client.DeleteMetadata(path); err != nil {
+				return fmt.Errorf("failed to delete secret: %w", err)
+			}
+			if client.DryRun() {
+				return printDryRun(cmd, client)
+			}
+			fmt.Fprintf(os.Stderr, "✓ Permanently deleted %s with all versions and metadata\n", path)
+
+		case len(versions) > 0:
+			if err := client.DeleteVersions(path, versions); err != nil {
+				return fmt.Errorf("failed to delete versions: %w", err)
+			}
+			if client.DryRun() {
+				return printDryRun(cmd, client)
+			}
+			fmt.Fprintf(os.Stderr, "✓ Soft-deleted version(s) %s of %s (restore with 'secrets undelete')\n", joinInts(versions), path)
+
+		default:
+			if err := client.DeleteSecret(path); err != nil {
+				return fmt.Errorf("failed to delete secret: %w", err)
+			}
+			if client.DryRun() {
+				return printDryRun(cmd, client)
+			}
+			if client.KVVersion(path) == 2 {
+				fmt.Fprintf(os.Stderr, "✓ Soft-deleted latest version of %s (restore with 'secrets undelete')\n", path)
+			} else {
+				fmt.Fprintf(os.Stderr, "✓ Secret deleted: %s\n", path)
+			}
+		}
+
+		return nil
+	},
+}
+
+var secretsUndeleteCmd = &cobra.Command{
+	Use:         "undelete [path]",
+	Short:       "Restore soft-deleted versions of a KV v2 secret",
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
-		
-		client, err := vault.NewClient()
+		versions, _ := cmd.Flags().GetIntSlice("versions")
+
+		client, err := newVaultClient()
 		if err != nil {
 			return fmt.Errorf("failed to create Vault client: %w", err)
 		}
 
-		if err := client.DeleteSecret(path); err != nil {
-			return fmt.Errorf("failed to delete secret: %w", err)
+		if err := confirmProtected(cmd, client, "undelete versions of "+path); err != nil {
+			return err
 		}
 
-		fmt.Printf("✓ Secret deleted: %s\n", path)
+		if err := client.UndeleteVersions(path, versions); err != nil {
+			return fmt.Errorf("failed to undelete versions: %w", err)
+		}
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
+
+		fmt.Fprintf(os.Stderr, "✓ Restored version(s) %s of %s\n", joinInts(versions), path)
+		return nil
+	},
+}
+
+var secretsDestroyCmd = &cobra.Command{
+	Use:         "destroy [path]",
+	Short:       "Permanently destroy versions of a KV v2 secret",
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		versions, _ := cmd.Flags().GetIntSlice("versions")
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		if err := confirmProtected(cmd, client, "permanently destroy versions of "+path); err != nil {
+			return err
+		}
+
+		if err := client.DestroyVersions(path, versions); err != nil {
+			return fmt.Errorf("failed to destroy versions: %w", err)
+		}
+		if client.DryRun() {
+			return printDryRun(cmd, client)
+		}
+
+		fmt.Fprintf(os.Stderr, "✓ Permanently destroyed version(s) %s of %s (cannot be undeleted)\n", joinInts(versions), path)
 		return nil
 	},
 }
@@ -163,12 +514,121 @@ var secretsDeleteCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(secretsCmd)
 	secretsCmd.AddCommand(secretsListCmd)
+	secretsCmd.AddCommand(secretsTreeCmd)
 	secretsCmd.AddCommand(secretsGetCmd)
 	secretsCmd.AddCommand(secretsPutCmd)
 	secretsCmd.AddCommand(secretsDeleteCmd)
+	secretsCmd.AddCommand(secretsPatchCmd)
+	secretsCmd.AddCommand(secretsVersionsCmd)
+	secretsCmd.AddCommand(secretsRollbackCmd)
+	secretsCmd.AddCommand(secretsUndeleteCmd)
+	secretsCmd.AddCommand(secretsDestroyCmd)
 
 	// Flags
-	secretsGetCmd.Flags().String("format", "table", "output format (table, json, yaml)")
+	secretsListCmd.Flags().BoolP("recursive", "r", false, "list all secrets below the path")
+	for _, c := range []*cobra.Command{secretsListCmd, secretsTreeCmd} {
+		c.Flags().Int("depth", 0, "maximum folder depth for recursive listing (0: unlimited)")
+		c.Flags().Int("concurrency", vault.DefaultWalkConcurrency, "parallel list requests for recursive listing")
+	}
 	secretsGetCmd.Flags().String("field", "", "specific field to retrieve")
-	secretsPutCmd.Flags().String("file", "", "JSON file containing secret data")
+	secretsGetCmd.Flags().Int("version", 0, "KV v2 version to read (default latest)")
+	secretsGetCmd.Flags().String("query", "", "JSONPath-style expression selecting values from data and metadata")
+	secretsGetCmd.MarkFlagsMutuallyExclusive("field", "query")
+	secretsGetCmd.Flags().Bool("reveal", false, "show values in table output")
+	secretsGetCmd.Flags().StringSlice("reveal-keys", nil, "show only these keys' values in table output, e.g. user,host")
+	secretsGetCmd.Flags().Bool("unmasked", false, "allow --field, --query and structured output where mask_values is set")
+	secretsRollbackCmd.Flags().Int("to", 0, "version to restore")
+	secretsDeleteCmd.Flags().IntSlice("versions", nil, "soft-delete these versions, e.g. 3,4")
+	secretsDeleteCmd.Flags().Bool("all-versions", false, "permanently delete all versions and metadata")
+	secretsDeleteCmd.MarkFlagsMutuallyExclusive("versions", "all-versions")
+	secretsUndeleteCmd.Flags().IntSlice("versions", nil, "versions to restore, e.g. 3,4")
+	secretsUndeleteCmd.MarkFlagRequired("versions")
+	secretsDestroyCmd.Flags().IntSlice("versions", nil, "versions to destroy, e.g. 3,4")
+	secretsDestroyCmd.MarkFlagRequired("versions")
+	secretsPutCmd.Flags().String("file", "", "JSON, YAML or dotenv file containing secret data (\"-\" for stdin)")
+	secretsPutCmd.Flags().String("input-format", "", "format of --file: json, yaml or dotenv (default: from the file extension)")
+	secretsPutCmd.Flags().Int("cas", 0, "only write if the current KV v2 version is N (0: secret must not exist)")
+	secretsPatchCmd.Flags().StringSlice("unset", nil, "remove a key (repeatable)")
+}
+
+// newRevealer picks the values shown in table output from --reveal and
+// --reveal-keys, unless the environment always masks them
+func newRevealer(cmd *cobra.Command, client *vault.Client) *output.Revealer {
+	all, _ := cmd.Flags().GetBool("reveal")
+	keys, _ := cmd.Flags().GetStringSlice("reveal-keys")
+
+	if client.Env.MaskValues {
+		if all || len(keys) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: values stay masked, environment %s has mask_values set\n", client.EnvName)
+		}
+		all, keys = false, nil
+	}
+	if all {
+		return output.NewRevealer(true, nil, nil)
+	}
+
+	maskKey, err := output.LoadMaskKey(filepath.Join(filepath.Dir(config.ConfigPath()), "mask_key"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: masked values are shown without fingerprints: %v\n", err)
+	}
+	return output.NewRevealer(false, keys, maskKey)
+}
+
+// requireUnmasked refuses output that shows values as they are, named by what,
+// in an environment with mask_values set unless --unmasked is given
+func requireUnmasked(cmd *cobra.Command, client *vault.Client, what string) error {
+	if !client.Env.MaskValues {
+		return nil
+	}
+	if unmasked, _ := cmd.Flags().GetBool("unmasked"); unmasked {
+		return nil
+	}
+	return fmt.Errorf("environment %s has mask_values set and %s prints values unmasked; add --unmasked to allow it", client.EnvName, what)
+}
+
+// printQuery prints the values q selects from the data and metadata of secret
+func printQuery(out *output.Printer, q *query.Query, secret *vault.Secret) error {
+	doc := map[string]interface{}{
+		"path":     secret.Path,
+		"version":  secret.Version,
+		"data":     secret.Data,
+		"metadata": secret.Metadata,
+	}
+	results := q.Eval(doc)
+	if len(results) == 0 {
+		return errors.New("query matched nothing")
+	}
+
+	if out.Format.Structured() {
+		if q.Single() {
+			return out.Print(results[0], nil)
+		}
+		return out.Print(results, nil)
+	}
+
+	for _, v := range results {
+		if b, ok := values.Binary(v); ok {
+			if _, err := os.Stdout.Write(b); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Println(formatValue(v))
+	}
+	return nil
+}
+
+// parseKeyValues parses key=value arguments, reading key=@file and key=-
+// values from files and stdin
+func parseKeyValues(args []string) (map[string]interface{}, error) {
+	return values.ParseArgs(args, os.Stdin)
+}
+
+// joinInts formats versions as "3, 4"
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
 }
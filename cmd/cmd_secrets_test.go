@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+// runPut runs "secrets put" against srv with args and stdin, and returns the
+// command's error
+func runPut(t *testing.T, srv *vaulttest.Server, stdin string, args ...string) error {
+	t.Helper()
+	dir := t.TempDir()
+
+	configFile := filepath.Join(dir, "config.yaml")
+	config := "current_environment: test\n" +
+		"auto_refresh: false\n" +
+		"cache_dir: " + filepath.Join(dir, "cache") + "\n" +
+		"token_file: " + filepath.Join(dir, "tokens") + "\n" +
+		"environments:\n  test:\n    vault_addr: " + srv.URL + "\n"
+	if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	stdinFile := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(stdinFile, []byte(stdin), 0600); err != nil {
+		t.Fatalf("failed to write stdin: %v", err)
+	}
+	f, err := os.Open(stdinFile)
+	if err != nil {
+		t.Fatalf("failed to open stdin: %v", err)
+	}
+	defer f.Close()
+
+	oldStdin := os.Stdin
+	os.Stdin = f
+	t.Cleanup(func() {
+		os.Stdin = oldStdin
+		// Flags keep their values between runs of the same command
+		for _, name := range []string{"file", "input-format", "config", "env"} {
+			flag := secretsPutCmd.Flags().Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+
+	rootCmd.SetArgs(append([]string{"secrets", "put", "--config", configFile, "--env", "test"}, args...))
+	return rootCmd.Execute()
+}
+
+func TestSecretsPut(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(file, []byte(`{"user": "app", "password": "from-file"}`), 0600); err != nil {
+		t.Fatalf("failed to write data file: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		stdin string
+		args  []string
+		want  map[string]interface{}
+	}{
+		{"file only", "", []string{"--file", file}, map[string]interface{}{"user": "app", "password": "from-file"}},
+		{"pairs only", "", []string{"user=app", "password=p"}, map[string]interface{}{"user": "app", "password": "p"}},
+		{"stdin value", "s3cret", []string{"user=app", "password=-"}, map[string]interface{}{"user": "app", "password": "s3cret"}},
+		{"stdin file", `{"token": "abc"}`, []string{"--file", "-"}, map[string]interface{}{"token": "abc"}},
+		{"file and pairs", "", []string{"--file", file, "password=override", "debug=true"}, map[string]interface{}{"user": "app", "password": "override", "debug": "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := vaulttest.NewServer(t)
+			args := append([]string{"secret/app"}, tt.args...)
+			if err := runPut(t, srv, tt.stdin, args...); err != nil {
+				t.Fatalf("secrets put %s error: %v", strings.Join(args, " "), err)
+			}
+			if got := srv.Data("secret/app"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stored %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretsPutNoData(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	if err := runPut(t, srv, "", "secret/app"); err == nil || !strings.Contains(err.Error(), "no data") {
+		t.Errorf("secrets put without data error = %v, want a no data error", err)
+	}
+	if err := runPut(t, srv, ""); err == nil {
+		t.Error("secrets put without a path should fail")
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dautovri/ruslan-cli/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Render config files with secrets",
+}
+
+var templateRenderCmd = &cobra.Command{
+	Use:   "render [template]",
+	Short: "Render a Go template with Vault secrets",
+	Long: `Render a Go text/template, looking secrets up in the selected environment.
+Each secret is read once however often it's used. Available functions:
+
+  secret "path" "key"   a value of a secret
+  secret "path"         all data of a secret, e.g. for range or toJSON
+  env "NAME"            an environment variable
+  base64 value          base64-encode a value
+  toJSON value          JSON-encode a value
+
+The output file is only written once the whole template has rendered. Use "-"
+to read the template from stdin.`,
+	Example: `  ruslan-cli template render config.yaml.tmpl -o config.yaml
+  ruslan-cli --env prod template render deployment.tmpl | kubectl apply -f -`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+
+		var text []byte
+		var err error
+		if args[0] == "-" {
+			text, err = io.ReadAll(os.Stdin)
+		} else {
+			text, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		renderer := render.New(client)
+		err = writeOutput(output, func(w io.Writer) error {
+			return renderer.Render(w, args[0], string(text))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to render template: %w", err)
+		}
+
+		if output != "" {
+			fmt.Fprintf(os.Stderr, "✓ Rendered %s (%d secret(s) read)\n", output, renderer.Paths())
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateRenderCmd)
+
+	templateRenderCmd.Flags().StringP("output", "o", "", "file to write (default: stdout)")
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/transfer"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/spf13/cobra"
+)
+
+var secretsExportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Export secrets to a JSON, YAML or dotenv document",
+	Long: `Export a secret, or with --recursive every secret below a folder, to a
+single document keyed by path relative to the folder. Secrets are read and
+written one at a time, so large trees aren't held in memory.
+
+The format is taken from --format, or from the extension of --output
+(.json, .yaml, .env), and defaults to JSON. Dotenv values that aren't
+strings are written as JSON and listed in a "# json:" comment, so that
+"secrets import" reads them back with their types.`,
+	Example: `  ruslan-cli secrets export secret/myapp -r -o myapp.json
+  ruslan-cli secrets export secret/myapp -r -f dotenv
+  ruslan-cli secrets export secret/myapp -r --metadata --versions -o snapshot.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		output, _ := cmd.Flags().GetString("output")
+		metadata, _ := cmd.Flags().GetBool("metadata")
+		versions, _ := cmd.Flags().GetBool("versions")
+
+		format, err := exportFormat(cmd, output)
+		if err != nil {
+			return err
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		export, err := client.PrepareExport(cmd.Context(), args[0], vault.ExportOptions{
+			Recursive: recursive,
+			Metadata:  metadata,
+			Versions:  versions,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list secrets: %w", err)
+		}
+		for _, walkErr := range export.Errors {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list %s\n", walkErr.Error())
+		}
+
+		err = writeOutput(output, func(w io.Writer) error {
+			doc, err := transfer.NewWriter(w, format, export.Root)
+			if err != nil {
+				return err
+			}
+			if err := export.Each(cmd.Context(), doc.WriteSecret); err != nil {
+				return err
+			}
+			return doc.Close()
+		})
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			fmt.Fprintf(os.Stderr, "✓ Exported %d secret(s) to %s\n", len(export.Paths), output)
+		}
+		if len(export.Errors) > 0 {
+			return fmt.Errorf("%d folder(s) could not be listed", len(export.Errors))
+		}
+		return nil
+	},
+}
+
+var secretsImportCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Import secrets from a JSON, YAML or dotenv document",
+	Long: `Import a document written by "secrets export", or a plain dotenv file, into
+the selected environment. Secrets are written below --prefix, or below the
+folder they were exported from.
+
+Without --apply only the plan of secrets to create, update or leave unchanged
+is shown. Writes use check-and-set, so secrets changed after the plan was made
+are not overwritten. Use "-" to read from stdin.`,
+	Example: `  ruslan-cli secrets import myapp.json --prefix secret/myapp
+  ruslan-cli secrets import myapp.json --prefix secret/myapp --apply
+  ruslan-cli secrets import .env --prefix secret/myapp/config --apply`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: supportsDryRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefix, _ := cmd.Flags().GetString("prefix")
+		inputFormat, _ := cmd.Flags().GetString("input-format")
+		apply, _ := cmd.Flags().GetBool("apply")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+
+		out, err := newPrinter(cmd)
+		if err != nil {
+			return err
+		}
+
+		doc, err := readDocument(args[0], inputFormat)
+		if err != nil {
+			return err
+		}
+
+		if prefix == "" {
+			prefix = doc.Root
+		}
+		prefix = strings.Trim(prefix, "/")
+		if prefix == "" {
+			return errors.New("the document doesn't record where it was exported from; use --prefix")
+		}
+
+		secrets := make(map[string]map[string]interface{}, len(doc.Secrets))
+		for rel, data := range doc.Secrets {
+			path := prefix
+			if rel != "" {
+				path += "/" + rel
+			}
+			secrets[path] = data
+		}
+
+		client, err := newVaultClient()
+		if err != nil {
+			return fmt.Errorf("failed to create Vault client: %w", err)
+		}
+
+		writes, err := client.PlanWrites(cmd.Context(), secrets, concurrency)
+		if err != nil {
+			return fmt.Errorf("failed to compare secrets: %w", err)
+		}
+
+		view := writesView()
+		view.Linef("Importing into %s:", client.EnvName)
+		appendWrites(view, writes)
+		if err := out.Print(writes, view); err != nil {
+			return err
+		}
+
+		var pending []*vault.PlannedWrite
+		counts := make(map[string]int)
+		for _, w := range writes {
+			counts[w.Action]++
+			if !w.Unchanged() {
+				pending = append(pending, w)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Plan: %d to create, %d to update, %d unchanged\n", counts[vault.ActionCreate], counts[vault.ActionUpdate], counts[vault.ActionUnchanged])
+
+		if len(pending) == 0 {
+			return nil
+		}
+		if !apply {
+			fmt.Fprintf(os.Stderr, "Run with --apply to write these changes\n")
+			return nil
+		}
+
+		if err := confirmProtected(cmd, client, fmt.Sprintf("import %d secret(s)", len(pending))); err != nil {
+			return err
+		}
+
+		failed := 0
+		for i, err := range client.ApplyWrites(cmd.Context(), pending, concurrency) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "✗ %s: %v\n", pending[i].Path, err)
+				failed++
+				continue
+			}
+			if !client.DryRun() {
+				fmt.Fprintf(os.Stderr, "✓ Wrote %s\n", pending[i].Path)
+			}
+		}
+		if client.DryRun() {
+			reportDryRun(client)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d secret(s) could not be written", failed, len(pending))
+		}
+		return nil
+	},
+}
+
+// readDocument reads an import document from file, or stdin for "-". The
+// format defaults to the file extension and then to JSON.
+func readDocument(file, format string) (*transfer.Document, error) {
+	if format == "" {
+		format = transfer.FormatFromPath(file)
+	}
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	doc, err := transfer.Read(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return doc, nil
+}
+
+// exportFormat returns the document format from --format, falling back to
+// the extension of file and then to JSON
+func exportFormat(cmd *cobra.Command, file string) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format = transfer.FormatFromPath(file)
+	}
+	if format == "" {
+		return transfer.FormatJSON, nil
+	}
+	return format, transfer.CheckFormat(format)
+}
+
+// writeOutput passes stdout, or a buffered file that only replaces path once
+// write succeeds, to write
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := write(w); err != nil {
+			w.Flush()
+			return err
+		}
+		return w.Flush()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsExportCmd)
+
+	secretsExportCmd.Flags().BoolP("recursive", "r", false, "export every secret below the path")
+	secretsExportCmd.Flags().StringP("format", "f", "", "document format: json, yaml or dotenv (default: from the extension of --output, then json)")
+	secretsExportCmd.Flags().StringP("output", "o", "", "file to write (default: stdout)")
+	secretsExportCmd.Flags().Bool("metadata", false, "include KV v2 metadata and version history")
+	secretsExportCmd.Flags().Bool("versions", false, "include the data of older KV v2 versions")
+
+	secretsCmd.AddCommand(secretsImportCmd)
+
+	secretsImportCmd.Flags().String("prefix", "", "folder to import into (default: the folder the document was exported from)")
+	secretsImportCmd.Flags().String("input-format", "", "document format: json, yaml or dotenv (default: from the file extension)")
+	secretsImportCmd.Flags().Bool("apply", false, "write the planned changes")
+	secretsImportCmd.Flags().Int("concurrency", vault.DefaultWalkConcurrency, "parallel reads and writes")
+}
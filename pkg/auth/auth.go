@@ -1,43 +1,52 @@
 package auth
 
 import (
-vaultapi "github.com/hashicorp/vault/api"
+	"errors"
+
+	vaultapi "github.com/hashicorp/vault/api"
 )
 
-// LoginWithToken authenticates with a token
-func LoginWithToken(client *vaultapi.Client, token string) error {
+// LoginWithToken authenticates with a token and returns its lookup-self data
+func LoginWithToken(client *vaultapi.Client, token string) (*vaultapi.Secret, error) {
 	client.SetToken(token)
-	
+
 	// Verify token is valid
-	_, err := client.Auth().Token().LookupSelf()
-	return err
+	return client.Auth().Token().LookupSelf()
 }
 
 // LoginWithUserPass authenticates with username/password
-func LoginWithUserPass(client *vaultapi.Client, username, password string) (string, error) {
+func LoginWithUserPass(client *vaultapi.Client, username, password string) (*vaultapi.SecretAuth, error) {
 	data := map[string]interface{}{
 		"password": password,
 	}
-	
+
 	secret, err := client.Logical().Write("auth/userpass/login/"+username, data)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	
-	return secret.Auth.ClientToken, nil
+
+	return loginAuth(secret)
 }
 
 // LoginWithAppRole authenticates with AppRole
-func LoginWithAppRole(client *vaultapi.Client, roleID, secretID string) (string, error) {
+func LoginWithAppRole(client *vaultapi.Client, roleID, secretID string) (*vaultapi.SecretAuth, error) {
 	data := map[string]interface{}{
 		"role_id":   roleID,
 		"secret_id": secretID,
 	}
-	
+
 	secret, err := client.Logical().Write("auth/approle/login", data)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	return loginAuth(secret)
+}
+
+// loginAuth extracts the auth block from a login response
+func loginAuth(secret *vaultapi.Secret) (*vaultapi.SecretAuth, error) {
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, errors.New("login response contained no token")
 	}
-	
-	return secret.Auth.ClientToken, nil
+	return secret.Auth, nil
 }
@@ -1,11 +1,12 @@
 package config
 
 import (
-"fmt"
-"os"
-"path/filepath"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
-"gopkg.in/yaml.v3"
+	"gopkg.in/yaml.v3"
 )
 
 // Environment represents a Vault environment configuration
@@ -19,57 +20,146 @@ type Environment struct {
 	VaultAddr   string `yaml:"vault_addr,omitempty"`
 	VaultPort   string `yaml:"vault_port"`
 	UseNipIO    bool   `yaml:"use_nipio"`
-	Token       string `yaml:"token,omitempty"` // Added Token field
+	// Protected environments ask for confirmation before secrets are written
+	Protected bool `yaml:"protected,omitempty"`
+	// MaskValues keeps secret values masked in table output even when
+	// --reveal is given
+	MaskValues bool `yaml:"mask_values,omitempty"`
+	// Deprecated: tokens live in the token store; this is only read to
+	// migrate configs written by older versions
+	Token string `yaml:"token,omitempty"`
 }
 
+// DefaultRefreshThreshold is how close to expiry a token gets renewed when
+// refresh_threshold isn't set
+const DefaultRefreshThreshold = 15 * time.Minute
+
+// EnvVar selects the environment for a single invocation without touching
+// the saved current environment
+const EnvVar = "RUSLAN_ENV"
+
 // Config represents the CLI configuration
 type Config struct {
 	CurrentEnvironment string                  `yaml:"current_environment"`
 	Environments       map[string]*Environment `yaml:"environments"`
 	TokenFile          string                  `yaml:"token_file"`
+	TokenStore         string                  `yaml:"token_store"`
+	TokenCommand       string                  `yaml:"token_command,omitempty"`
 	CacheDir           string                  `yaml:"cache_dir"`
 	OutputFormat       string                  `yaml:"output_format"`
 	AutoRefresh        bool                    `yaml:"auto_refresh"`
+	RefreshThreshold   string                  `yaml:"refresh_threshold,omitempty"`
+
+	projectFile string
+	settings    []Setting
+}
+
+// ResolveEnvironment returns the environment to use for this invocation.
+// The override (usually the --env flag) wins over RUSLAN_ENV, which wins over
+// CurrentEnvironment. The config itself is never modified.
+func (c *Config) ResolveEnvironment(override string) (string, *Environment, error) {
+	name := override
+	if name == "" {
+		name = os.Getenv(EnvVar)
+	}
+	if name == "" {
+		name = c.CurrentEnvironment
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("no environment selected (use --env, %s or 'env use')", EnvVar)
+	}
+
+	env, ok := c.Environments[name]
+	if !ok || env == nil {
+		return name, nil, fmt.Errorf("environment not found: %s", name)
+	}
+	return name, env, nil
 }
 
-// ConfigPath returns the default config file path
+// configPath overrides the default user config location (see SetPath)
+var configPath string
+
+// SetPath overrides the user config file location, e.g. from --config
+func SetPath(path string) {
+	configPath = path
+}
+
+// RefreshThresholdDuration parses refresh_threshold, falling back to the default
+func (c *Config) RefreshThresholdDuration() (time.Duration, error) {
+	if c.RefreshThreshold == "" {
+		return DefaultRefreshThreshold, nil
+	}
+	d, err := time.ParseDuration(c.RefreshThreshold)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refresh_threshold %q: %w", c.RefreshThreshold, err)
+	}
+	return d, nil
+}
+
+// ConfigPath returns the user config file path
 func ConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
 	home, _ := os.UserHomeDir()
 	return filepath.Join(home, ".ruslan-cli", "config.yaml")
 }
 
-// Load reads the configuration file
+// Load reads the user configuration and merges the nearest project file
+// (.ruslan-cli.yaml, searched from the working directory upwards) over it.
+// The result cannot be saved; use LoadUser for changes that must persist.
 func Load() (*Config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	return load(wd)
+}
+
+// LoadUser reads only the user configuration file, creating it with
+// defaults if it doesn't exist
+func LoadUser() (*Config, error) {
 	configPath := ConfigPath()
 
 	// Create default config if doesn't exist
-if _, err := os.Stat(configPath); os.IsNotExist(err) {
-cfg := DefaultConfig()
-if err := cfg.Save(); err != nil {
-return nil, fmt.Errorf("failed to create default config: %w", err)
-}
-return cfg, nil
-}
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		cfg := DefaultConfig()
+		if err := cfg.Save(); err != nil {
+			return nil, fmt.Errorf("failed to create default config: %w", err)
+		}
+	}
 
-data, err := os.ReadFile(configPath)
-if err != nil {
-return nil, fmt.Errorf("failed to read config: %w", err)
-}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
 
-var cfg Config
-if err := yaml.Unmarshal(data, &cfg); err != nil {
-return nil, fmt.Errorf("failed to parse config: %w", err)
-}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
 
-return &cfg, nil
+	tree, err := parseTree(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	origins := make(map[string]string)
+	recordOrigins(tree, "", configPath, origins)
+	cfg.settings = flatten(tree, "", origins, nil)
+
+	return &cfg, nil
 }
 
 // Save writes the configuration file (method on Config)
 func (c *Config) Save() error {
-configPath := ConfigPath()
-configDir := filepath.Dir(configPath)
+	if c.projectFile != "" {
+		return fmt.Errorf("config merged with %s cannot be saved, load the user config instead", c.projectFile)
+	}
 
-// Create config directory if doesn't exist
+	configPath := ConfigPath()
+	configDir := filepath.Dir(configPath)
+
+	// Create config directory if doesn't exist
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -92,33 +182,35 @@ func DefaultConfig() *Config {
 
 	return &Config{
 		CurrentEnvironment: "dev",
-	Environments: map[string]*Environment{
-		"dev": {
-			Name:        "Development",
-			ProjectID:   "homework-475918",
-			Region:      "us-central1",
-			ClusterName: "dev-gke-cluster",
-			Namespace:   "vault",
-			ServiceName: "vault",
-			VaultAddr:   "https://vault-dev.dautov.dev",
-			VaultPort:   "443",
-			UseNipIO:    false,
-		},
-		"prod": {
-			Name:        "Production",
-			ProjectID:   "homework-475918",
-			Region:      "us-central1",
-			ClusterName: "prod-gke-cluster",
-			Namespace:   "vault",
-			ServiceName: "vault",
-			VaultAddr:   "https://vault.dautov.dev",
-			VaultPort:   "443",
-			UseNipIO:    false,
-		},
+		Environments: map[string]*Environment{
+			"dev": {
+				Name:        "Development",
+				ProjectID:   "homework-475918",
+				Region:      "us-central1",
+				ClusterName: "dev-gke-cluster",
+				Namespace:   "vault",
+				ServiceName: "vault",
+				VaultAddr:   "https://vault-dev.dautov.dev",
+				VaultPort:   "443",
+				UseNipIO:    false,
+			},
+			"prod": {
+				Name:        "Production",
+				ProjectID:   "homework-475918",
+				Region:      "us-central1",
+				ClusterName: "prod-gke-cluster",
+				Namespace:   "vault",
+				ServiceName: "vault",
+				VaultAddr:   "https://vault.dautov.dev",
+				VaultPort:   "443",
+				UseNipIO:    false,
+			},
 		},
-		TokenFile:    filepath.Join(home, ".ruslan-cli", "tokens"),
-		CacheDir:     filepath.Join(home, ".ruslan-cli", "cache"),
-		OutputFormat: "table",
-		AutoRefresh:  true,
+		TokenFile:        filepath.Join(home, ".ruslan-cli", "tokens"),
+		TokenStore:       "file",
+		CacheDir:         filepath.Join(home, ".ruslan-cli", "cache"),
+		OutputFormat:     "table",
+		AutoRefresh:      true,
+		RefreshThreshold: DefaultRefreshThreshold.String(),
 	}
 }
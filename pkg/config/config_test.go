@@ -81,3 +81,149 @@ func TestEnvironmentValidation(t *testing.T) {
 		t.Error("Expected 'nonexistent' environment to not exist")
 	}
 }
+
+func TestResolveEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		override string
+		envVar   string
+		want     string
+		wantErr  bool
+	}{
+		{name: "saved current environment", want: "dev"},
+		{name: "env var wins over saved", envVar: "prod", want: "prod"},
+		{name: "override wins over env var", override: "dev", envVar: "prod", want: "dev"},
+		{name: "unknown environment", override: "staging", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVar, tt.envVar)
+			cfg := DefaultConfig()
+
+			name, env, err := cfg.ResolveEnvironment(tt.override)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got environment %s", name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if name != tt.want || env != cfg.Environments[tt.want] {
+				t.Errorf("ResolveEnvironment() = %s, want %s", name, tt.want)
+			}
+			if cfg.CurrentEnvironment != "dev" {
+				t.Errorf("CurrentEnvironment changed to %s", cfg.CurrentEnvironment)
+			}
+		})
+	}
+}
+
+func TestLoadMergesProjectFile(t *testing.T) {
+	tempDir := t.TempDir()
+	SetPath(filepath.Join(tempDir, "home", "config.yaml"))
+	t.Cleanup(func() { SetPath("") })
+
+	repo := filepath.Join(tempDir, "repo")
+	workDir := filepath.Join(repo, "deploy", "app")
+	if err := os.MkdirAll(workDir, 0700); err != nil {
+		t.Fatalf("Failed to create work dir: %v", err)
+	}
+
+	project := `current_environment: prod
+token_file: /tmp/elsewhere
+environments:
+  dev:
+    vault_addr: http://127.0.0.1:8200
+    token: from-project
+  staging:
+    vault_addr: https://vault-staging.example.com
+`
+	projectPath := filepath.Join(repo, ProjectFileName)
+	if err := os.WriteFile(projectPath, []byte(project), 0600); err != nil {
+		t.Fatalf("Failed to write project file: %v", err)
+	}
+
+	cfg, err := load(workDir)
+	if err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+
+	if cfg.ProjectFile() != projectPath {
+		t.Errorf("ProjectFile() = %s, want %s", cfg.ProjectFile(), projectPath)
+	}
+	if cfg.CurrentEnvironment != "prod" {
+		t.Errorf("Expected project current_environment=prod, got: %s", cfg.CurrentEnvironment)
+	}
+
+	dev := cfg.Environments["dev"]
+	if dev.VaultAddr != "http://127.0.0.1:8200" {
+		t.Errorf("Expected project vault_addr for dev, got: %s", dev.VaultAddr)
+	}
+	if dev.ClusterName != "dev-gke-cluster" {
+		t.Errorf("Expected user cluster_name to survive merge, got: %s", dev.ClusterName)
+	}
+	if dev.Token != "" {
+		t.Errorf("Project file must not set tokens, got: %s", dev.Token)
+	}
+	if _, ok := cfg.Environments["staging"]; !ok {
+		t.Error("Expected 'staging' environment from project file")
+	}
+	if cfg.TokenFile == "/tmp/elsewhere" {
+		t.Error("Project file must not override token_file")
+	}
+
+	if got := cfg.Origin("environments.dev.vault_addr"); got != projectPath {
+		t.Errorf("Origin(vault_addr) = %s, want %s", got, projectPath)
+	}
+	if got := cfg.Origin("environments.dev.cluster_name"); got != ConfigPath() {
+		t.Errorf("Origin(cluster_name) = %s, want %s", got, ConfigPath())
+	}
+
+	if err := cfg.Save(); err == nil {
+		t.Error("Expected Save() on a merged config to fail")
+	}
+}
+
+func TestProjectFileCannotLiftProtection(t *testing.T) {
+	tempDir := t.TempDir()
+	userPath := filepath.Join(tempDir, "home", "config.yaml")
+	SetPath(userPath)
+	t.Cleanup(func() { SetPath("") })
+
+	user := DefaultConfig()
+	user.Environments["prod"].Protected = true
+	user.Environments["prod"].MaskValues = true
+	if err := user.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	repo := filepath.Join(tempDir, "repo")
+	if err := os.MkdirAll(repo, 0700); err != nil {
+		t.Fatalf("Failed to create repo: %v", err)
+	}
+	project := `environments:
+  prod:
+    protected: false
+    mask_values: false
+  dev:
+    protected: true
+    mask_values: true
+`
+	if err := os.WriteFile(filepath.Join(repo, ProjectFileName), []byte(project), 0600); err != nil {
+		t.Fatalf("Failed to write project file: %v", err)
+	}
+
+	cfg, err := load(repo)
+	if err != nil {
+		t.Fatalf("load() error: %v", err)
+	}
+	if prod := cfg.Environments["prod"]; !prod.Protected || !prod.MaskValues {
+		t.Errorf("project file lifted prod settings: protected=%t mask_values=%t", prod.Protected, prod.MaskValues)
+	}
+	if dev := cfg.Environments["dev"]; !dev.Protected || !dev.MaskValues {
+		t.Errorf("project file couldn't set dev settings: protected=%t mask_values=%t", dev.Protected, dev.MaskValues)
+	}
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the project-local config file, usually committed to an
+// infrastructure repository
+const ProjectFileName = ".ruslan-cli.yaml"
+
+// projectKeys are the top-level settings a project file may override. Token
+// storage settings are left out on purpose so that a cloned repository can't
+// redirect where credentials are kept.
+var projectKeys = map[string]bool{
+	"current_environment": true,
+	"output_format":       true,
+	"environments":        true,
+}
+
+// Setting is a single effective configuration value and the file it came from
+type Setting struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
+// FindProjectFile walks up from dir and returns the first project file found,
+// or "" if there is none
+func FindProjectFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to check %s: %w", path, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ProjectFile returns the project file merged into this config, if any
+func (c *Config) ProjectFile() string {
+	return c.projectFile
+}
+
+// Settings returns every effective value with its origin, sorted by key.
+// Token values are redacted.
+func (c *Config) Settings() []Setting {
+	return c.settings
+}
+
+// Origin returns the file a setting came from, or "" if it isn't set anywhere
+func (c *Config) Origin(key string) string {
+	for _, s := range c.settings {
+		if s.Key == key {
+			return s.Origin
+		}
+	}
+	return ""
+}
+
+// load reads the user config and merges the project file found from dir
+func load(dir string) (*Config, error) {
+	user, err := LoadUser()
+	if err != nil {
+		return nil, err
+	}
+
+	projectPath, err := FindProjectFile(dir)
+	if err != nil || projectPath == "" {
+		return user, err
+	}
+
+	userData, err := os.ReadFile(ConfigPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	userTree, err := parseTree(userData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	projectData, err := os.ReadFile(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config: %w", err)
+	}
+	projectTree, err := parseTree(projectData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse project config %s: %w", projectPath, err)
+	}
+	projectTree = filterProjectTree(projectTree)
+
+	origins := make(map[string]string)
+	recordOrigins(userTree, "", ConfigPath(), origins)
+	recordOrigins(projectTree, "", projectPath, origins)
+	merged := mergeTrees(userTree, projectTree)
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge project config: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to merge project config: %w", err)
+	}
+
+	cfg.projectFile = projectPath
+	cfg.settings = flatten(merged, "", origins, nil)
+	return &cfg, nil
+}
+
+// parseTree decodes YAML into nested maps, treating an empty document as empty
+func parseTree(data []byte) (map[string]interface{}, error) {
+	tree := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		tree = make(map[string]interface{})
+	}
+	return tree, nil
+}
+
+// filterProjectTree drops settings a project file isn't allowed to change
+func filterProjectTree(tree map[string]interface{}) map[string]interface{} {
+	filtered := make(map[string]interface{})
+	for key, value := range tree {
+		if projectKeys[key] {
+			filtered[key] = value
+		}
+	}
+
+	if envs, ok := filtered["environments"].(map[string]interface{}); ok {
+		for _, env := range envs {
+			if fields, ok := env.(map[string]interface{}); ok {
+				delete(fields, "token")
+				// A checked-out repository may protect an environment or mask
+				// its values, but not lift what the user config sets
+				for _, key := range []string{"protected", "mask_values"} {
+					if enabled, ok := fields[key].(bool); ok && !enabled {
+						delete(fields, key)
+					}
+				}
+			}
+		}
+	}
+	return filtered
+}
+
+// mergeTrees deep-merges override into base; maps merge per key, anything
+// else in override replaces the base value
+func mergeTrees(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range override {
+		baseMap, baseIsMap := merged[key].(map[string]interface{})
+		overrideMap, overrideIsMap := value.(map[string]interface{})
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeTrees(baseMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// recordOrigins marks every leaf of tree as coming from origin
+func recordOrigins(tree map[string]interface{}, prefix, origin string, origins map[string]string) {
+	for key, value := range tree {
+		path := joinKey(prefix, key)
+		if child, ok := value.(map[string]interface{}); ok {
+			recordOrigins(child, path, origin, origins)
+			continue
+		}
+		origins[path] = origin
+	}
+}
+
+// flatten turns the merged tree into sorted settings
+func flatten(tree map[string]interface{}, prefix string, origins map[string]string, out []Setting) []Setting {
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := joinKey(prefix, key)
+		if child, ok := tree[key].(map[string]interface{}); ok {
+			out = flatten(child, path, origins, out)
+			continue
+		}
+
+		value := ""
+		if tree[key] != nil {
+			value = fmt.Sprint(tree[key])
+		}
+		if key == "token" && value != "" {
+			value = "<redacted>"
+		}
+		out = append(out, Setting{Key: path, Value: value, Origin: origins[path]})
+	}
+	return out
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return strings.Join([]string{prefix, key}, ".")
+}
@@ -0,0 +1,67 @@
+// Package confirm asks before a command changes a protected environment. The
+// environment's name has to be typed on a terminal, or given with --yes and
+// --confirm-env in scripts.
+package confirm
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Gate holds what a command was given to approve a change
+type Gate struct {
+	Yes        bool     // --yes
+	ConfirmEnv []string // --confirm-env
+	// Terminal is where the answer is typed, or nil without a terminal
+	Terminal io.Reader
+	// Prompt is where the question is asked
+	Prompt io.Writer
+}
+
+// Check lets action in env go ahead if --yes and --confirm-env <env> were
+// both given, or if the name of env is typed on the terminal
+func (g Gate) Check(env, action string) error {
+	if g.Yes && slices.Contains(g.ConfirmEnv, env) {
+		return nil
+	}
+
+	if g.Terminal == nil {
+		return fmt.Errorf("%s is a protected environment and there is no terminal to confirm on: use --yes --confirm-env %s to %s", env, env, action)
+	}
+
+	fmt.Fprintf(g.Prompt, "You are about to %s in %s, a protected environment.\n", action, env)
+	fmt.Fprintf(g.Prompt, "Type %q to confirm: ", env)
+	answer, err := bufio.NewReader(g.Terminal).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	if strings.TrimSpace(answer) != env {
+		return errors.New("confirmation did not match, nothing was changed")
+	}
+	return nil
+}
+
+// OpenTerminal returns stdin if it is a terminal and otherwise the controlling
+// terminal, so that input piped to a command is never taken as the answer
+func OpenTerminal() (io.ReadCloser, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	f, err := os.Open(terminalPath)
+	if err != nil {
+		return nil, err
+	}
+	if !term.IsTerminal(int(f.Fd())) {
+		f.Close()
+		return nil, fmt.Errorf("%s is not a terminal", terminalPath)
+	}
+	return f, nil
+}
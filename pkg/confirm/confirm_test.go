@@ -0,0 +1,50 @@
+package confirm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGateCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		gate    Gate
+		wantErr string
+	}{
+		{"yes and confirm-env", Gate{Yes: true, ConfirmEnv: []string{"dev", "prod"}}, ""},
+		{"yes for another env", Gate{Yes: true, ConfirmEnv: []string{"dev"}}, "no terminal"},
+		{"confirm-env without yes", Gate{ConfirmEnv: []string{"prod"}}, "no terminal"},
+		{"typed name", Gate{Terminal: strings.NewReader("prod\n")}, ""},
+		{"typed other name", Gate{Terminal: strings.NewReader("dev\n")}, "did not match"},
+		{"no answer", Gate{Terminal: strings.NewReader("")}, "failed to read confirmation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt bytes.Buffer
+			tt.gate.Prompt = &prompt
+			err := tt.gate.Check("prod", "write secret/app")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGatePrompt(t *testing.T) {
+	var prompt bytes.Buffer
+	gate := Gate{Terminal: strings.NewReader("prod\n"), Prompt: &prompt}
+	if err := gate.Check("prod", "write secret/app"); err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	if !strings.Contains(prompt.String(), "write secret/app in prod") || !strings.Contains(prompt.String(), `Type "prod"`) {
+		t.Errorf("prompt = %q", prompt.String())
+	}
+}
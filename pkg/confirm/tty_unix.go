@@ -0,0 +1,6 @@
+//go:build !windows
+
+package confirm
+
+// terminalPath is the controlling terminal of the process
+const terminalPath = "/dev/tty"
@@ -0,0 +1,6 @@
+//go:build windows
+
+package confirm
+
+// terminalPath is the console input of the process
+const terminalPath = "CONIN$"
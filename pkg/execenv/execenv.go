@@ -0,0 +1,165 @@
+// Package execenv runs a command with secrets in its environment. Values are
+// only passed to the child process and never written to disk.
+package execenv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+)
+
+// Source is the data of one secret to expose
+type Source struct {
+	Path string
+	Data map[string]interface{}
+}
+
+// MapOptions controls how secret keys become variable names
+type MapOptions struct {
+	Prefix    string
+	Uppercase bool
+}
+
+// Var is an environment variable and the secret key it came from
+type Var struct {
+	Name   string
+	Value  string
+	Source string // path#key
+}
+
+// VarName turns a secret key into a variable name: the prefix is added,
+// characters other than letters, digits and "_" become "_", and a leading
+// digit gets a "_" in front
+func VarName(key string, opts MapOptions) string {
+	name := opts.Prefix + key
+	if opts.Uppercase {
+		name = strings.ToUpper(name)
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// Map turns the keys of sources into variables sorted by name. Two keys that
+// map to the same name are an error rather than one silently winning.
+func Map(sources []Source, opts MapOptions) ([]Var, error) {
+	byName := make(map[string]Var)
+	for _, source := range sources {
+		for key, raw := range source.Data {
+			if _, ok := values.Binary(raw); ok {
+				return nil, fmt.Errorf("%s#%s holds binary data, which can't be passed in an environment variable", source.Path, key)
+			}
+			value, err := values.String(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s#%s: %w", source.Path, key, err)
+			}
+
+			v := Var{Name: VarName(key, opts), Value: value, Source: source.Path + "#" + key}
+			if existing, ok := byName[v.Name]; ok {
+				return nil, fmt.Errorf("%s is set by both %s and %s", v.Name, existing.Source, v.Source)
+			}
+			byName[v.Name] = v
+		}
+	}
+
+	vars := make([]Var, 0, len(byName))
+	for _, v := range byName {
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars, nil
+}
+
+// Environ merges vars over base (usually os.Environ()) and returns the names
+// of inherited variables that were replaced
+func Environ(base []string, vars []Var) ([]string, []string) {
+	set := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		set[v.Name] = true
+	}
+
+	env := make([]string, 0, len(base)+len(vars))
+	var replaced []string
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if set[name] {
+			replaced = append(replaced, name)
+			continue
+		}
+		env = append(env, kv)
+	}
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
+	}
+	return env, replaced
+}
+
+// Run starts name with args and env, forwards the signals this process
+// receives to it (except those the terminal already sent it, like Ctrl-C),
+// and returns its exit code once it finishes. A child killed by a signal
+// reports 128 plus the signal number, like a shell.
+func Run(ctx context.Context, name string, args, env []string) (int, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Catch signals before starting so none slips through to kill us instead
+	signals := make(chan os.Signal, 8)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	foreground := inForeground()
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if forward(sig, foreground) {
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-ctx.Done():
+				_ = cmd.Process.Kill()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	close(done)
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return 0, err
+	}
+	return exitCode(cmd.ProcessState), nil
+}
+
+// forward reports whether sig has to be passed on to the child. Signals from
+// the terminal already reached a child in its foreground process group, and
+// a second SIGINT often means "shut down now".
+func forward(sig os.Signal, foreground bool) bool {
+	return !foreground || !terminalSignals[sig]
+}
@@ -0,0 +1,101 @@
+package execenv
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+)
+
+func TestMap(t *testing.T) {
+	sources := []Source{
+		{Path: "secret/app/db", Data: map[string]interface{}{"user": "app", "pool-size": 5.0}},
+		{Path: "secret/app/api", Data: map[string]interface{}{"token": "abc", "2fa": "x"}},
+	}
+
+	vars, err := Map(sources, MapOptions{Prefix: "app_", Uppercase: true})
+	if err != nil {
+		t.Fatalf("Map() error: %v", err)
+	}
+	var got []string
+	for _, v := range vars {
+		got = append(got, v.Name+"="+v.Value)
+	}
+	want := []string{"APP_2FA=x", "APP_POOL_SIZE=5", "APP_TOKEN=abc", "APP_USER=app"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %v, want %v", got, want)
+	}
+
+	if name := VarName("2fa", MapOptions{}); name != "_2fa" {
+		t.Errorf("VarName(2fa) = %s, want _2fa", name)
+	}
+
+	collide := []Source{
+		{Path: "secret/a", Data: map[string]interface{}{"db-url": "1"}},
+		{Path: "secret/b", Data: map[string]interface{}{"DB_URL": "2"}},
+	}
+	_, err = Map(collide, MapOptions{Uppercase: true})
+	if err == nil || !strings.Contains(err.Error(), "secret/a#db-url") || !strings.Contains(err.Error(), "secret/b#DB_URL") {
+		t.Errorf("Map(colliding) error = %v, want both sources named", err)
+	}
+}
+
+func TestMapBinary(t *testing.T) {
+	sources := []Source{{Path: "secret/app/tls", Data: map[string]interface{}{"cert": values.FromBytes([]byte{0, 1, 2})}}}
+	_, err := Map(sources, MapOptions{})
+	if err == nil || !strings.Contains(err.Error(), "secret/app/tls#cert holds binary data") {
+		t.Errorf("Map(binary) error = %v, want a binary data error", err)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	env, replaced := Environ([]string{"PATH=/bin", "TOKEN=old"}, []Var{{Name: "TOKEN", Value: "new"}})
+	if want := []string{"PATH=/bin", "TOKEN=new"}; !reflect.DeepEqual(env, want) {
+		t.Errorf("Environ() = %v, want %v", env, want)
+	}
+	if !reflect.DeepEqual(replaced, []string{"TOKEN"}) {
+		t.Errorf("replaced = %v, want [TOKEN]", replaced)
+	}
+}
+
+// TestHelperProcess is the child started by TestRun
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("EXECENV_HELPER") != "1" {
+		return
+	}
+	if os.Getenv("SECRET") != "s3cr3t" {
+		os.Exit(1)
+	}
+	code, _ := strconv.Atoi(os.Getenv("EXIT_CODE"))
+	os.Exit(code)
+}
+
+func TestRun(t *testing.T) {
+	for _, want := range []int{0, 3} {
+		env := append(os.Environ(), "EXECENV_HELPER=1", "SECRET=s3cr3t", "EXIT_CODE="+strconv.Itoa(want))
+		code, err := Run(context.Background(), os.Args[0], []string{"-test.run=TestHelperProcess"}, env)
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		if code != want {
+			t.Errorf("Run() exit code = %d, want %d", code, want)
+		}
+	}
+
+	if _, err := Run(context.Background(), "does-not-exist-ruslan-cli", nil, nil); err == nil {
+		t.Error("Run() of a missing command should fail")
+	}
+}
+
+func TestForward(t *testing.T) {
+	if forward(os.Interrupt, true) {
+		t.Error("Ctrl-C in the foreground reached the child already and shouldn't be forwarded")
+	}
+	if !forward(os.Interrupt, false) {
+		t.Error("an interrupt outside the foreground should be forwarded")
+	}
+}
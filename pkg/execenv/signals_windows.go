@@ -0,0 +1,22 @@
+//go:build windows
+
+package execenv
+
+import "os"
+
+// forwardedSignals are caught so Ctrl+C, which the console already delivers
+// to the child, doesn't stop this process before the child exits
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+// terminalSignals are delivered to the child by the console itself
+var terminalSignals = map[os.Signal]bool{os.Interrupt: true}
+
+// inForeground reports whether the child shares the console's signals, which
+// it always does on Windows
+func inForeground() bool {
+	return true
+}
+
+func exitCode(state *os.ProcessState) int {
+	return state.ExitCode()
+}
@@ -0,0 +1,184 @@
+// Package generate produces random secret values from specs such as
+// "password:32:symbols", using crypto/rand
+package generate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Character sets for passwords
+const (
+	alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	symbols      = "!#%&*+-.:=?@^_~"
+)
+
+// defaultPasswordLength is used for "password" without a length
+const defaultPasswordLength = 32
+
+// PublicKeySuffix is appended to a key's name to store the public half of a
+// generated key pair
+const PublicKeySuffix = "_pub"
+
+// maxLength bounds lengths so a typo can't ask for gigabytes
+const maxLength = 4096
+
+// Spec is a parsed generator spec
+type Spec struct {
+	Kind    string
+	Length  int
+	Symbols bool
+}
+
+// Parse reads a spec:
+//
+//	password[:length[:symbols]]  random letters and digits, optionally symbols
+//	hex[:length]                 random hex characters (default 64)
+//	uuid                         a random (version 4) UUID
+//	ed25519                      an OpenSSH ed25519 private key
+func Parse(s string) (Spec, error) {
+	parts := strings.Split(s, ":")
+	spec := Spec{Kind: parts[0]}
+	args := parts[1:]
+
+	switch spec.Kind {
+	case "password":
+		spec.Length = defaultPasswordLength
+		if len(args) > 2 || (len(args) == 2 && args[1] != "symbols") {
+			return Spec{}, fmt.Errorf("invalid generator %q: use password[:length[:symbols]]", s)
+		}
+		spec.Symbols = len(args) == 2
+	case "hex":
+		spec.Length = 64
+		if len(args) > 1 {
+			return Spec{}, fmt.Errorf("invalid generator %q: use hex[:length]", s)
+		}
+	case "uuid", "ed25519":
+		if len(args) > 0 {
+			return Spec{}, fmt.Errorf("invalid generator %q: %s takes no options", s, spec.Kind)
+		}
+	default:
+		return Spec{}, fmt.Errorf("unknown generator %q (use password, hex, uuid or ed25519)", spec.Kind)
+	}
+
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 || n > maxLength {
+			return Spec{}, fmt.Errorf("invalid length in generator %q: must be between 1 and %d", s, maxLength)
+		}
+		spec.Length = n
+	}
+	return spec, nil
+}
+
+// Generate produces a new random value. For a key pair that's the private key;
+// use Values to get the public key too.
+func (s Spec) Generate() (string, error) {
+	switch s.Kind {
+	case "password":
+		chars := alphanumeric
+		if s.Symbols {
+			chars += symbols
+		}
+		return randomString(chars, s.Length)
+	case "hex":
+		b := make([]byte, (s.Length+1)/2)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(b)[:s.Length], nil
+	case "uuid":
+		return uuid()
+	case "ed25519":
+		private, _, err := sshKeyPair()
+		return private, err
+	}
+	return "", fmt.Errorf("unknown generator %q", s.Kind)
+}
+
+// Keys returns the keys Values stores for key
+func (s Spec) Keys(key string) []string {
+	if s.Kind == "ed25519" {
+		return []string{key, key + PublicKeySuffix}
+	}
+	return []string{key}
+}
+
+// Values generates the values to store under key: the value itself, and for
+// a key pair also the public key under key+PublicKeySuffix
+func (s Spec) Values(key string) (map[string]string, error) {
+	if s.Kind == "ed25519" {
+		private, public, err := sshKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{key: private, key + PublicKeySuffix: public}, nil
+	}
+
+	value, err := s.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{key: value}, nil
+}
+
+// Value parses spec and generates a value from it
+func Value(spec string) (string, error) {
+	s, err := Parse(spec)
+	if err != nil {
+		return "", err
+	}
+	return s.Generate()
+}
+
+// randomString picks n characters from chars uniformly
+func randomString(chars string, n int) (string, error) {
+	max := big.NewInt(int64(len(chars)))
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = chars[idx.Int64()]
+	}
+	return string(b), nil
+}
+
+func uuid() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+// sshKeyPair returns a new ed25519 key pair as an OpenSSH private key and an
+// authorized_keys line
+func sshKeyPair() (string, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		return "", "", err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return "", "", err
+	}
+	authorized := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPublic)), "\n")
+	return string(pem.EncodeToMemory(block)), authorized, nil
+}
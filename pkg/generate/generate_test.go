@@ -0,0 +1,85 @@
+package generate
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		spec    string
+		pattern string
+	}{
+		{"password", `^[A-Za-z0-9]{32}$`},
+		{"password:12", `^[A-Za-z0-9]{12}$`},
+		{"password:40:symbols", `^[A-Za-z0-9!#%&*+\-.:=?@^_~]{40}$`},
+		{"hex", `^[0-9a-f]{64}$`},
+		{"hex:7", `^[0-9a-f]{7}$`},
+		{"uuid", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+	for _, tt := range tests {
+		value, err := Value(tt.spec)
+		if err != nil {
+			t.Errorf("Value(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if !regexp.MustCompile(tt.pattern).MatchString(value) {
+			t.Errorf("Value(%q) = %q, want match for %s", tt.spec, value, tt.pattern)
+		}
+	}
+
+	a, _ := Value("password")
+	b, _ := Value("password")
+	if a == b {
+		t.Error("two generated passwords are equal")
+	}
+
+	// With enough characters every class of a symbol password shows up
+	long, _ := Value("password:4096:symbols")
+	if !strings.ContainsAny(long, symbols) {
+		t.Error("symbols password contains no symbols")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "pin", "password:0", "password:abc", "password:8:emoji", "hex:1:2", "uuid:4", "ed25519:256", "hex:100000"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
+
+func TestValuesKeyPair(t *testing.T) {
+	spec, err := Parse("ed25519")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	values, err := spec.Values("deploy_key")
+	if err != nil {
+		t.Fatalf("Values() error: %v", err)
+	}
+
+	if keys := spec.Keys("deploy_key"); len(keys) != len(values) || values[keys[0]] == "" || values[keys[1]] == "" {
+		t.Errorf("Keys() = %v, want the keys of Values() %v", keys, values)
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(values["deploy_key"]))
+	if err != nil {
+		t.Fatalf("private key doesn't parse: %v", err)
+	}
+	public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(values["deploy_key"+PublicKeySuffix]))
+	if err != nil {
+		t.Fatalf("public key doesn't parse: %v", err)
+	}
+	if public.Type() != ssh.KeyAlgoED25519 || string(public.Marshal()) != string(signer.PublicKey().Marshal()) {
+		t.Error("public key doesn't match the private key")
+	}
+
+	single, _ := Spec{Kind: "uuid"}.Values("id")
+	if len(single) != 1 || single["id"] == "" {
+		t.Errorf("Values(uuid) = %v, want one value", single)
+	}
+}
@@ -0,0 +1,139 @@
+// Package k8s builds Kubernetes Secret manifests from Vault secrets
+package k8s
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+)
+
+// Labels and annotations recording where a Secret came from
+const (
+	LabelManagedBy        = "app.kubernetes.io/managed-by"
+	LabelEnvironment      = "ruslan-cli/environment"
+	AnnotationSource      = "ruslan-cli/source"
+	AnnotationClusterName = "ruslan-cli/cluster-name"
+
+	managedBy = "ruslan-cli"
+)
+
+// validKey matches the keys Kubernetes accepts in a Secret
+var validKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// Secret is a v1/Secret manifest; fields are in the order kubectl prints them
+type Secret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   Metadata          `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+// Metadata is the object metadata of a Secret
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Source is one Vault secret to include
+type Source struct {
+	Path    string
+	Version int // 0 on KV v1
+	Data    map[string]interface{}
+}
+
+// Options describes the Secret to build
+type Options struct {
+	Name        string
+	Namespace   string
+	Type        string // defaults to Opaque
+	Environment string
+	ClusterName string
+	// StringData writes plain values to stringData instead of base64 in data
+	StringData bool
+}
+
+// BuildSecret merges the data of sources into one Secret. A key found in two
+// sources, or one Kubernetes doesn't accept, is an error.
+func BuildSecret(opts Options, sources []Source) (*Secret, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("a name is required")
+	}
+
+	secret := &Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Type:       opts.Type,
+		Metadata: Metadata{
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+			Labels:    map[string]string{LabelManagedBy: managedBy},
+		},
+	}
+	if secret.Type == "" {
+		secret.Type = "Opaque"
+	}
+	if opts.Environment != "" {
+		secret.Metadata.Labels[LabelEnvironment] = opts.Environment
+	}
+
+	byKey := make(map[string][]byte)
+	origin := make(map[string]string)
+	refs := make([]string, 0, len(sources))
+	for _, source := range sources {
+		ref := source.Path
+		if source.Version > 0 {
+			ref += "@" + strconv.Itoa(source.Version)
+		}
+		refs = append(refs, ref)
+
+		for key, raw := range source.Data {
+			if !validKey.MatchString(key) {
+				return nil, fmt.Errorf("%s: key %q is not a valid Kubernetes Secret key", source.Path, key)
+			}
+			if first, ok := origin[key]; ok {
+				return nil, fmt.Errorf("key %q is in both %s and %s", key, first, source.Path)
+			}
+			// Binary values go into data as the bytes they stand for
+			if b, ok := values.Binary(raw); ok {
+				if opts.StringData {
+					return nil, fmt.Errorf("%s: %s holds binary data, which can't go in stringData", source.Path, key)
+				}
+				byKey[key] = b
+				origin[key] = source.Path
+				continue
+			}
+			value, err := values.String(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", source.Path, key, err)
+			}
+			byKey[key] = []byte(value)
+			origin[key] = source.Path
+		}
+	}
+
+	secret.Metadata.Annotations = map[string]string{AnnotationSource: strings.Join(refs, ",")}
+	if opts.ClusterName != "" {
+		secret.Metadata.Annotations[AnnotationClusterName] = opts.ClusterName
+	}
+
+	if opts.StringData {
+		secret.StringData = make(map[string]string, len(byKey))
+		for key, value := range byKey {
+			secret.StringData[key] = string(value)
+		}
+		return secret, nil
+	}
+	secret.Data = make(map[string]string, len(byKey))
+	for key, value := range byKey {
+		secret.Data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+	return secret, nil
+}
@@ -0,0 +1,92 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBuildSecret(t *testing.T) {
+	sources := []Source{
+		{Path: "secret/app/db", Version: 3, Data: map[string]interface{}{"password": "p@ss", "port": 5432.0}},
+		{Path: "secret/app/api", Version: 1, Data: map[string]interface{}{"api.key": "abc"}},
+	}
+
+	secret, err := BuildSecret(Options{Name: "app-secrets", Namespace: "app", Environment: "dev", ClusterName: "dev-gke"}, sources)
+	if err != nil {
+		t.Fatalf("BuildSecret() error: %v", err)
+	}
+
+	out, err := yaml.Marshal(secret)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error: %v", err)
+	}
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+    name: app-secrets
+    namespace: app
+    labels:
+        app.kubernetes.io/managed-by: ruslan-cli
+        ruslan-cli/environment: dev
+    annotations:
+        ruslan-cli/cluster-name: dev-gke
+        ruslan-cli/source: secret/app/db@3,secret/app/api@1
+type: Opaque
+data:
+    api.key: YWJj
+    password: cEBzcw==
+    port: NTQzMg==
+`
+	if string(out) != want {
+		t.Errorf("manifest:\n%s\nwant:\n%s", out, want)
+	}
+
+	plain, err := BuildSecret(Options{Name: "app", StringData: true}, sources[:1])
+	if err != nil {
+		t.Fatalf("BuildSecret(stringData) error: %v", err)
+	}
+	if plain.Data != nil || plain.StringData["password"] != "p@ss" {
+		t.Errorf("stringData secret = %+v", plain)
+	}
+}
+
+func TestBuildSecretErrors(t *testing.T) {
+	dup := []Source{
+		{Path: "secret/a", Data: map[string]interface{}{"key": "1"}},
+		{Path: "secret/b", Data: map[string]interface{}{"key": "2"}},
+	}
+	if _, err := BuildSecret(Options{Name: "x"}, dup); err == nil || !strings.Contains(err.Error(), "secret/b") {
+		t.Errorf("duplicate key error = %v", err)
+	}
+
+	bad := []Source{{Path: "secret/a", Data: map[string]interface{}{"no/slash": "1"}}}
+	if _, err := BuildSecret(Options{Name: "x"}, bad); err == nil {
+		t.Error("invalid key should fail")
+	}
+
+	if _, err := BuildSecret(Options{}, nil); err == nil {
+		t.Error("missing name should fail")
+	}
+}
+
+func TestBuildSecretBinary(t *testing.T) {
+	der := []byte{0x30, 0x82, 0x00, 0xff}
+	sources := []Source{{Path: "secret/app/tls", Data: map[string]interface{}{"cert.der": values.FromBytes(der)}}}
+
+	secret, err := BuildSecret(Options{Name: "tls"}, sources)
+	if err != nil {
+		t.Fatalf("BuildSecret() error: %v", err)
+	}
+	if got, want := secret.Data["cert.der"], base64.StdEncoding.EncodeToString(der); got != want {
+		t.Errorf("data cert.der = %q, want %q", got, want)
+	}
+
+	_, err = BuildSecret(Options{Name: "tls", StringData: true}, sources)
+	if err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Errorf("BuildSecret(stringData) error = %v, want a binary data error", err)
+	}
+}
@@ -0,0 +1,315 @@
+// Package manifest describes the secrets each environment must hold, with
+// values taken from encrypted files or generators, and plans the Vault writes
+// needed to reconcile them
+package manifest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/dautovri/ruslan-cli/pkg/generate"
+	"github.com/dautovri/ruslan-cli/pkg/sealbox"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// PassphraseEnvVar supplies the passphrase of encrypted value files
+const PassphraseEnvVar = "RUSLAN_MANIFEST_PASSPHRASE"
+
+// Manifest is the desired state of secrets per environment
+type Manifest struct {
+	Version      int                         `yaml:"version"`
+	Environments map[string]*EnvironmentSpec `yaml:"environments"`
+
+	dir string
+}
+
+// EnvironmentSpec lists the secrets of one environment
+type EnvironmentSpec struct {
+	// Prune removes keys the manifest doesn't list from every secret
+	Prune   bool                   `yaml:"prune"`
+	Secrets map[string]*SecretSpec `yaml:"secrets"`
+}
+
+// SecretSpec lists the keys of one secret
+type SecretSpec struct {
+	// Prune overrides the environment's prune setting for this secret
+	Prune *bool               `yaml:"prune"`
+	Keys  map[string]*KeySpec `yaml:"keys"`
+}
+
+// KeySpec says where a value comes from: a key in an encrypted file, or a
+// generator. Generated values are only created when the key doesn't exist.
+type KeySpec struct {
+	File     string `yaml:"file"`
+	Key      string `yaml:"key"` // key in File, defaults to the key's own name
+	Generate string `yaml:"generate"`
+}
+
+// Load reads and validates a manifest. Files are resolved relative to it.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+
+	m.dir = filepath.Dir(path)
+	return &m, nil
+}
+
+func (m *Manifest) validate() error {
+	if m.Version != 1 {
+		return fmt.Errorf("unsupported version %d (expected 1)", m.Version)
+	}
+	if len(m.Environments) == 0 {
+		return errors.New("no environments")
+	}
+
+	for envName, env := range m.Environments {
+		if env == nil {
+			return fmt.Errorf("environment %s is empty", envName)
+		}
+		for path, secret := range env.Secrets {
+			if secret == nil || len(secret.Keys) == 0 {
+				return fmt.Errorf("%s: %s has no keys", envName, path)
+			}
+			stored := make(map[string]bool)
+			for _, key := range sortedKeys(secret.Keys) {
+				spec := secret.Keys[key]
+				if err := spec.validate(); err != nil {
+					return fmt.Errorf("%s: %s: %s: %w", envName, path, key, err)
+				}
+				// A key pair also stores <key>_pub, which mustn't clash with another key
+				for _, k := range spec.keys(key) {
+					if stored[k] {
+						return fmt.Errorf("%s: %s: key %s is given twice", envName, path, k)
+					}
+					stored[k] = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (k *KeySpec) validate() error {
+	switch {
+	case k == nil || (k.File == "" && k.Generate == ""):
+		return errors.New("needs a file or a generator")
+	case k.File != "" && k.Generate != "":
+		return errors.New("can't have both a file and a generator")
+	case k.Key != "" && k.File == "":
+		return errors.New("key is only used with file")
+	case k.Generate != "":
+		_, err := generate.Parse(k.Generate)
+		return err
+	}
+	return nil
+}
+
+// keys returns the keys spec stores for key
+func (k *KeySpec) keys(key string) []string {
+	if k.Generate == "" {
+		return []string{key}
+	}
+	spec, _ := generate.Parse(k.Generate)
+	return spec.Keys(key)
+}
+
+// sortedKeys returns the names of a secret's keys, sorted
+func sortedKeys(keys map[string]*KeySpec) []string {
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvironmentNames returns the environments in the manifest, sorted
+func (m *Manifest) EnvironmentNames() []string {
+	names := make([]string, 0, len(m.Environments))
+	for name := range m.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Resolver turns key specs into values, opening each encrypted file once
+type Resolver struct {
+	manifest   *Manifest
+	passphrase func() (string, error)
+	cached     string
+	files      map[string]map[string]interface{}
+}
+
+// NewResolver returns a resolver for m. The passphrase func is only called
+// when the first encrypted file is opened.
+func NewResolver(m *Manifest, passphrase func() (string, error)) *Resolver {
+	return &Resolver{manifest: m, passphrase: passphrase, files: make(map[string]map[string]interface{})}
+}
+
+// Desired returns the data a secret should hold, given its current data (nil
+// if it doesn't exist). Generated keys keep their current value, as does the
+// public half of a generated key pair, and keys the manifest doesn't list are
+// kept unless prune is set.
+func (r *Resolver) Desired(spec *SecretSpec, prune bool, current map[string]interface{}) (map[string]interface{}, error) {
+	desired := make(map[string]interface{})
+	if !prune {
+		for key, value := range current {
+			desired[key] = value
+		}
+	}
+
+	for key, keySpec := range spec.Keys {
+		switch {
+		case keySpec.Generate != "":
+			gen, err := generate.Parse(keySpec.Generate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			// Like "secrets generate", nothing is regenerated once any of its keys exists
+			exists := false
+			for _, k := range gen.Keys(key) {
+				if value, ok := current[k]; ok {
+					desired[k] = value
+					exists = true
+				}
+			}
+			if exists {
+				continue
+			}
+			values, err := gen.Values(key)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			for k, v := range values {
+				desired[k] = v
+			}
+		default:
+			fileKey := keySpec.Key
+			if fileKey == "" {
+				fileKey = key
+			}
+			value, err := r.fileValue(keySpec.File, fileKey)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			desired[key] = value
+		}
+	}
+	return desired, nil
+}
+
+// fileValue reads key from a sealed YAML file of key/value pairs
+func (r *Resolver) fileValue(file, key string) (interface{}, error) {
+	path := file
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.manifest.dir, path)
+	}
+
+	values, ok := r.files[path]
+	if !ok {
+		sealed, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		if r.cached == "" {
+			pass, err := r.passphrase()
+			if err != nil {
+				return nil, err
+			}
+			r.cached = pass
+		}
+		data, err := sealbox.Open(r.cached, sealed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		// YAML numbers decode as int and float64, but Vault returns json.Number
+		if values, err = vault.NormalizeData(values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		r.files[path] = values
+	}
+
+	value, ok := values[key]
+	if !ok {
+		return nil, fmt.Errorf("%s has no key %q", file, key)
+	}
+	return value, nil
+}
+
+// EnvironmentPlan is the set of writes that reconcile one environment
+type EnvironmentPlan struct {
+	Environment string                `json:"environment" yaml:"environment"`
+	Writes      []*vault.PlannedWrite `json:"writes" yaml:"writes"`
+}
+
+// Pending returns the writes that would change something
+func (p *EnvironmentPlan) Pending() []*vault.PlannedWrite {
+	var pending []*vault.PlannedWrite
+	for _, w := range p.Writes {
+		if !w.Unchanged() {
+			pending = append(pending, w)
+		}
+	}
+	return pending
+}
+
+// Plan compares the manifest's secrets for env with what client's Vault
+// holds. forcePrune prunes unmanaged keys regardless of the manifest.
+func (r *Resolver) Plan(ctx context.Context, client *vault.Client, env string, forcePrune bool) (*EnvironmentPlan, error) {
+	envSpec, ok := r.manifest.Environments[env]
+	if !ok {
+		return nil, fmt.Errorf("environment %s is not in the manifest", env)
+	}
+
+	paths := make([]string, 0, len(envSpec.Secrets))
+	for path := range envSpec.Secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// The resolver isn't safe for concurrent use, so secrets are planned one by one
+	writes := make([]*vault.PlannedWrite, 0, len(paths))
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		spec := envSpec.Secrets[path]
+		prune := envSpec.Prune || forcePrune
+		if spec.Prune != nil && !forcePrune {
+			prune = *spec.Prune
+		}
+
+		w, err := client.PlanUpdate(path, func(current map[string]interface{}) (map[string]interface{}, error) {
+			return r.Desired(spec, prune, current)
+		})
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, w)
+	}
+
+	return &EnvironmentPlan{Environment: env, Writes: writes}, nil
+}
@@ -0,0 +1,189 @@
+package manifest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/generate"
+	"github.com/dautovri/ruslan-cli/pkg/sealbox"
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func writeManifest(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "secrets.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+	return path
+}
+
+func TestDesired(t *testing.T) {
+	dir := t.TempDir()
+
+	sealed, err := sealbox.Seal("pass", []byte("db_password: from-file\napi_key: abc\n"))
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dev.enc"), sealed, 0600); err != nil {
+		t.Fatalf("failed to write sealed file: %v", err)
+	}
+
+	m, err := Load(writeManifest(t, dir, `version: 1
+environments:
+  dev:
+    secrets:
+      secret/app/db:
+        keys:
+          password: {file: dev.enc, key: db_password}
+          api_key: {file: dev.enc}
+          session: {generate: "hex:16"}
+`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got := m.EnvironmentNames(); !reflect.DeepEqual(got, []string{"dev"}) {
+		t.Errorf("EnvironmentNames() = %v", got)
+	}
+
+	opened := 0
+	r := NewResolver(m, func() (string, error) {
+		opened++
+		return "pass", nil
+	})
+	spec := m.Environments["dev"].Secrets["secret/app/db"]
+
+	current := map[string]interface{}{"session": "keep-me", "unmanaged": "x"}
+	desired, err := r.Desired(spec, false, current)
+	if err != nil {
+		t.Fatalf("Desired() error: %v", err)
+	}
+	want := map[string]interface{}{
+		"password":  "from-file",
+		"api_key":   "abc",
+		"session":   "keep-me",
+		"unmanaged": "x",
+	}
+	if !reflect.DeepEqual(desired, want) {
+		t.Errorf("Desired() = %v, want %v", desired, want)
+	}
+
+	pruned, err := r.Desired(spec, true, nil)
+	if err != nil {
+		t.Fatalf("Desired(prune) error: %v", err)
+	}
+	if _, ok := pruned["unmanaged"]; ok {
+		t.Error("prune kept an unmanaged key")
+	}
+	if session, _ := pruned["session"].(string); len(session) != 16 {
+		t.Errorf("generated session = %q, want 16 hex characters", session)
+	}
+	if opened != 1 {
+		t.Errorf("passphrase asked %d times, want once", opened)
+	}
+}
+
+func TestDesiredKeyPair(t *testing.T) {
+	dir := t.TempDir()
+	m, err := Load(writeManifest(t, dir, `version: 1
+environments:
+  dev:
+    secrets:
+      secret/app/deploy:
+        keys:
+          ssh_key: {generate: ed25519}
+`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	r := NewResolver(m, func() (string, error) { return "", nil })
+	spec := m.Environments["dev"].Secrets["secret/app/deploy"]
+
+	desired, err := r.Desired(spec, true, nil)
+	if err != nil {
+		t.Fatalf("Desired() error: %v", err)
+	}
+	private, _ := desired["ssh_key"].(string)
+	public, _ := desired["ssh_key"+generate.PublicKeySuffix].(string)
+	if !strings.Contains(private, "OPENSSH PRIVATE KEY") || !strings.HasPrefix(public, "ssh-ed25519 ") {
+		t.Errorf("Desired() = %v, want a private key and its public key", desired)
+	}
+
+	// Pruning keeps both halves of an existing pair
+	current := map[string]interface{}{"ssh_key": "private", "ssh_key_pub": "public", "unmanaged": "x"}
+	pruned, err := r.Desired(spec, true, current)
+	if err != nil {
+		t.Fatalf("Desired(prune) error: %v", err)
+	}
+	want := map[string]interface{}{"ssh_key": "private", "ssh_key_pub": "public"}
+	if !reflect.DeepEqual(pruned, want) {
+		t.Errorf("Desired(prune) = %v, want %v", pruned, want)
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"version":   "version: 2\nenvironments: {dev: {}}\n",
+		"plaintext": "version: 1\nenvironments:\n  dev:\n    secrets:\n      secret/a:\n        keys:\n          k: {value: plain}\n",
+		"no source": "version: 1\nenvironments:\n  dev:\n    secrets:\n      secret/a:\n        keys:\n          k: {}\n",
+		"both":      "version: 1\nenvironments:\n  dev:\n    secrets:\n      secret/a:\n        keys:\n          k: {file: f.enc, generate: uuid}\n",
+		"generator": "version: 1\nenvironments:\n  dev:\n    secrets:\n      secret/a:\n        keys:\n          k: {generate: pin}\n",
+		"key pair":  "version: 1\nenvironments:\n  dev:\n    secrets:\n      secret/a:\n        keys:\n          k: {generate: ed25519}\n          k_pub: {generate: uuid}\n",
+	}
+	for name, content := range tests {
+		if _, err := Load(writeManifest(t, t.TempDir(), content)); err == nil {
+			t.Errorf("%s: Load() should fail", name)
+		} else if !strings.Contains(err.Error(), "manifest") {
+			t.Errorf("%s: error %q doesn't name the manifest", name, err)
+		}
+	}
+}
+
+func TestPlanNumbersUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	sealed, err := sealbox.Seal("pass", []byte("port: 5432\nratio: 0.5\n"))
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dev.enc"), sealed, 0600); err != nil {
+		t.Fatalf("failed to write sealed file: %v", err)
+	}
+	m, err := Load(writeManifest(t, dir, `version: 1
+environments:
+  dev:
+    secrets:
+      secret/app/db:
+        keys:
+          port: {file: dev.enc}
+          ratio: {file: dev.enc}
+`))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	srv := vaulttest.NewServer(t)
+	srv.Put("secret/app/db", map[string]interface{}{"port": 5432, "ratio": 0.5})
+	cfg := config.DefaultConfig()
+	cfg.CacheDir = t.TempDir()
+	cfg.Environments = map[string]*config.Environment{"dev": {VaultAddr: srv.URL}}
+	client, err := vault.NewClientWithStore(cfg, tokenstore.NewFileStore(t.TempDir()), "dev")
+	if err != nil {
+		t.Fatalf("NewClientWithStore() error: %v", err)
+	}
+
+	r := NewResolver(m, func() (string, error) { return "pass", nil })
+	plan, err := r.Plan(context.Background(), client, "dev", false)
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if pending := plan.Pending(); len(pending) > 0 {
+		t.Errorf("Plan() = %s %+v, want no changes", pending[0].Action, pending[0].Changes)
+	}
+}
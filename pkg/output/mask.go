@@ -0,0 +1,91 @@
+package output
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// Mask hides a value behind its length and a short fingerprint, so values can
+// be told apart and compared across environments without being shown. The
+// fingerprint is an HMAC under key, which keeps short values from being
+// guessed from it; without a key it is left out.
+func Mask(key []byte, value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	if len(key) == 0 {
+		return fmt.Sprintf("******** (%d chars)", utf8.RuneCountInString(value))
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return fmt.Sprintf("******** (%d chars, hmac:%s)", utf8.RuneCountInString(value), hex.EncodeToString(mac.Sum(nil)[:4]))
+}
+
+// LoadMaskKey reads the fingerprint key from path, creating a random one the
+// first time
+func LoadMaskKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read mask key: %w", err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate mask key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create mask key directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		// Another process created it first
+		return os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save mask key: %w", err)
+	}
+	if _, err := f.Write(key); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to save mask key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to save mask key: %w", err)
+	}
+	return key, nil
+}
+
+// Revealer decides which keys are shown in clear text
+type Revealer struct {
+	all     bool
+	keys    map[string]bool
+	maskKey []byte
+}
+
+// NewRevealer reveals all keys if all is set, and otherwise only keys. Other
+// values are masked with fingerprints under maskKey.
+func NewRevealer(all bool, keys []string, maskKey []byte) *Revealer {
+	r := &Revealer{all: all, keys: make(map[string]bool, len(keys)), maskKey: maskKey}
+	for _, k := range keys {
+		r.keys[k] = true
+	}
+	return r
+}
+
+// Value returns value as is if key is revealed, and masked otherwise
+func (r *Revealer) Value(key, value string) string {
+	if r.all || r.keys[key] {
+		return value
+	}
+	return Mask(r.maskKey, value)
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	key := []byte("test key")
+	masked := Mask(key, "s3cret")
+	if strings.Contains(masked, "s3cret") || !strings.Contains(masked, "6 chars") {
+		t.Errorf("Mask() = %q", masked)
+	}
+	if Mask(key, "s3cret") != masked || Mask(key, "other!") == masked {
+		t.Error("Mask() fingerprints should be stable and differ between values")
+	}
+	if Mask([]byte("other key"), "s3cret") == masked {
+		t.Error("Mask() fingerprints should depend on the key")
+	}
+	if got := Mask(nil, "s3cret"); got != "******** (6 chars)" {
+		t.Errorf("Mask() without a key = %q", got)
+	}
+	if Mask(key, "") != "(empty)" {
+		t.Errorf("Mask(\"\") = %q", Mask(key, ""))
+	}
+
+	r := NewRevealer(false, []string{"user"}, key)
+	if r.Value("user", "app") != "app" || r.Value("password", "p") == "p" {
+		t.Error("Revealer should only show listed keys")
+	}
+	if NewRevealer(true, nil, key).Value("password", "p") != "p" {
+		t.Error("Revealer with all should show every key")
+	}
+}
+
+func TestLoadMaskKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dir", "mask_key")
+	key, err := LoadMaskKey(path)
+	if err != nil {
+		t.Fatalf("LoadMaskKey() error: %v", err)
+	}
+	if len(key) != 32 {
+		t.Errorf("LoadMaskKey() returned %d bytes", len(key))
+	}
+
+	again, err := LoadMaskKey(path)
+	if err != nil {
+		t.Fatalf("LoadMaskKey() error: %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("LoadMaskKey() should return the saved key")
+	}
+}
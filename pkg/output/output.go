@@ -0,0 +1,165 @@
+// Package output renders command results as a table, JSON, YAML, plain text
+// or a Go template. Structured formats encode the result itself; the table
+// and plain formats render a view of it built by the command.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output format names
+const (
+	FormatTable      = "table"
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatPlain      = "plain"
+	FormatGoTemplate = "go-template"
+)
+
+// Format is a parsed output format
+type Format struct {
+	Name string
+	tmpl *template.Template
+}
+
+// ParseFormat reads a format name, or "go-template=<template>" for a Go
+// template executed against the JSON form of the result
+func ParseFormat(s string) (Format, error) {
+	if text, ok := strings.CutPrefix(s, FormatGoTemplate+"="); ok {
+		tmpl, err := template.New("output").Option("missingkey=error").Funcs(template.FuncMap{
+			"json": toJSON,
+		}).Parse(text)
+		if err != nil {
+			return Format{}, fmt.Errorf("invalid output template: %w", err)
+		}
+		return Format{Name: FormatGoTemplate, tmpl: tmpl}, nil
+	}
+
+	switch s {
+	case FormatTable, FormatJSON, FormatYAML, FormatPlain:
+		return Format{Name: s}, nil
+	case FormatGoTemplate:
+		return Format{}, fmt.Errorf("go-template needs a template: --format 'go-template={{.key}}'")
+	}
+	return Format{}, fmt.Errorf("unsupported output format %q (use table, json, yaml, plain or go-template=...)", s)
+}
+
+// Structured reports whether the format encodes the result itself rather than
+// a human-readable view of it
+func (f Format) Structured() bool {
+	return f.Name == FormatJSON || f.Name == FormatYAML || f.Name == FormatGoTemplate
+}
+
+// View is the human-readable form of a result for the table and plain
+// formats: a *Table, *Fields, Lines or *Text
+type View interface {
+	writeTable(w io.Writer) error
+	writePlain(w io.Writer) error
+}
+
+// Printer writes results in one format
+type Printer struct {
+	Format Format
+	w      io.Writer
+}
+
+// NewPrinter returns a printer writing to w
+func NewPrinter(w io.Writer, format Format) *Printer {
+	return &Printer{Format: format, w: w}
+}
+
+// Print writes v in a structured format, or view for the table and plain
+// formats. Without a view v is written as YAML instead.
+func (p *Printer) Print(v interface{}, view View) error {
+	switch {
+	case p.Format.Name == FormatJSON:
+		return writeJSON(p.w, v)
+	case p.Format.Name == FormatYAML || (!p.Format.Structured() && view == nil):
+		return writeYAML(p.w, v)
+	case p.Format.Name == FormatGoTemplate:
+		return p.writeTemplate(v)
+	case p.Format.Name == FormatPlain:
+		return view.writePlain(p.w)
+	default:
+		return view.writeTable(p.w)
+	}
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// writeYAML encodes v through its JSON form, so field names, key order and
+// numbers are the same as in JSON output
+func writeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	plainStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// plainStyle drops the JSON quoting and flow style from a parsed document so
+// it's written as block YAML
+func plainStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		plainStyle(c)
+	}
+}
+
+// writeTemplate executes the template against the JSON form of v, so it uses
+// the same field names as JSON output
+func (p *Printer) writeTemplate(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	if err := p.Format.tmpl.Execute(p.w, generic); err != nil {
+		return fmt.Errorf("failed to render output template: %w", err)
+	}
+	return nil
+}
+
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// SortedKeys returns the keys of m in order, for views with a stable order
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,124 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type result struct {
+	Name  string                 `json:"name"`
+	Count int                    `json:"count"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+func printed(t *testing.T, format string, v interface{}, view View) string {
+	t.Helper()
+	f, err := ParseFormat(format)
+	if err != nil {
+		t.Fatalf("ParseFormat(%q) error: %v", format, err)
+	}
+	var buf bytes.Buffer
+	if err := NewPrinter(&buf, f).Print(v, view); err != nil {
+		t.Fatalf("Print(%s) error: %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestPrintStructured(t *testing.T) {
+	v := result{
+		Name:  "app",
+		Count: 2,
+		Data:  map[string]interface{}{"port": json.Number("8080"), "b": "<x>", "a": "line1\nline2"},
+	}
+
+	wantJSON := `{
+  "name": "app",
+  "count": 2,
+  "data": {
+    "a": "line1\nline2",
+    "b": "<x>",
+    "port": 8080
+  }
+}
+`
+	if got := printed(t, "json", v, nil); got != wantJSON {
+		t.Errorf("json:\n%s\nwant:\n%s", got, wantJSON)
+	}
+
+	// Fields keep their order and numbers stay numbers
+	wantYAML := `name: app
+count: 2
+data:
+  a: |-
+    line1
+    line2
+  b: <x>
+  port: 8080
+`
+	if got := printed(t, "yaml", v, nil); got != wantYAML {
+		t.Errorf("yaml:\n%s\nwant:\n%s", got, wantYAML)
+	}
+
+	tmpl := `go-template={{.name}}:{{range $k, $v := .data}} {{$k}}{{end}} {{json .count}}`
+	if got := printed(t, tmpl, v, nil); got != "app: a b port 2" {
+		t.Errorf("go-template = %q", got)
+	}
+}
+
+func TestPrintViews(t *testing.T) {
+	table := NewTable("Key", "Value")
+	table.Append("a", "1")
+	table.Append("b", "2")
+
+	if got := printed(t, "plain", nil, table); got != "a\t1\nb\t2\n" {
+		t.Errorf("plain table = %q", got)
+	}
+	if got := printed(t, "table", nil, table); !strings.Contains(got, "KEY") || !strings.Contains(got, "  b   |") {
+		t.Errorf("table =\n%s", got)
+	}
+
+	var fields Fields
+	fields.Add("Environment", "dev")
+	fields.Add("Region", "us-central1")
+	if got := printed(t, "table", nil, &fields); got != "Environment: dev\nRegion:      us-central1\n" {
+		t.Errorf("fields table = %q", got)
+	}
+	if got := printed(t, "plain", nil, &fields); got != "Environment\tdev\nRegion\tus-central1\n" {
+		t.Errorf("fields plain = %q", got)
+	}
+
+	if got := printed(t, "table", nil, Lines{"secret/a", "secret/b"}); got != "secret/a\nsecret/b\n" {
+		t.Errorf("lines = %q", got)
+	}
+
+	text := &Text{Rows: NewTable("Path", "Key", "Change")}
+	text.Linef("~ %s", "secret/app")
+	text.Linef("    + %s", "token")
+	text.Rows.Append("secret/app", "token", "added")
+	if got := printed(t, "table", nil, text); got != "~ secret/app\n    + token\n" {
+		t.Errorf("text table = %q", got)
+	}
+	if got := printed(t, "plain", nil, text); got != "secret/app\ttoken\tadded\n" {
+		t.Errorf("text plain = %q", got)
+	}
+
+	// Without a view the result is shown as YAML
+	if got := printed(t, "table", map[string]int{"n": 1}, nil); got != "n: 1\n" {
+		t.Errorf("table without view = %q", got)
+	}
+}
+
+func TestParseFormatErrors(t *testing.T) {
+	for _, s := range []string{"", "xml", "go-template", "go-template={{.a"} {
+		if _, err := ParseFormat(s); err == nil {
+			t.Errorf("ParseFormat(%q) should fail", s)
+		}
+	}
+
+	f, _ := ParseFormat("go-template={{.missing}}")
+	if err := NewPrinter(&bytes.Buffer{}, f).Print(map[string]string{"a": "1"}, nil); err == nil {
+		t.Error("template with a missing key should fail")
+	}
+}
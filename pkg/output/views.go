@@ -0,0 +1,113 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Table is a view with a header and rows
+type Table struct {
+	header []string
+	rows   [][]string
+}
+
+// NewTable returns an empty table with the given column names
+func NewTable(header ...string) *Table {
+	return &Table{header: header}
+}
+
+// Append adds a row
+func (t *Table) Append(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *Table) writeTable(w io.Writer) error {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(t.header)
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(t.rows)
+	table.Render()
+	return nil
+}
+
+// writePlain writes rows as tab-separated values without a header
+func (t *Table) writePlain(w io.Writer) error {
+	for _, row := range t.rows {
+		if _, err := fmt.Fprintln(w, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fields is a view of a single object as labelled values
+type Fields struct {
+	names  []string
+	values []string
+}
+
+// Add appends a labelled value
+func (f *Fields) Add(name, value string) {
+	f.names = append(f.names, name)
+	f.values = append(f.values, value)
+}
+
+// writeTable aligns the values after their labels
+func (f *Fields) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for i, name := range f.names {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, f.values[i])
+	}
+	return tw.Flush()
+}
+
+// writePlain writes one tab-separated label and value per line
+func (f *Fields) writePlain(w io.Writer) error {
+	for i, name := range f.names {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", name, f.values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lines is a view of one value per line, the same in both formats
+type Lines []string
+
+func (l Lines) writeTable(w io.Writer) error {
+	return l.writePlain(w)
+}
+
+func (l Lines) writePlain(w io.Writer) error {
+	for _, line := range l {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Text is a view the command lays out itself for the table format, with
+// tab-separated rows for the plain format
+type Text struct {
+	Lines Lines
+	Rows  *Table
+}
+
+// Linef appends a formatted line to the table layout
+func (t *Text) Linef(format string, args ...interface{}) {
+	t.Lines = append(t.Lines, fmt.Sprintf(format, args...))
+}
+
+func (t *Text) writeTable(w io.Writer) error {
+	return t.Lines.writeTable(w)
+}
+
+func (t *Text) writePlain(w io.Writer) error {
+	return t.Rows.writePlain(w)
+}
@@ -0,0 +1,279 @@
+// Package query extracts values from decoded JSON documents with a subset of
+// JSONPath:
+//
+//	$.data.password       a key ($ and the leading dot are optional)
+//	.data['api.key']      a key with special characters
+//	.data.hosts[0]        an array element; negative indexes count from the end
+//	.data.hosts[*]        every element of an array or value of an object
+//	.data['user','pass']  several keys
+//	.data.user,.metadata.version  several paths
+//
+// A string holding a JSON object or array is descended into like the value it
+// encodes, so keys inside JSON-encoded secret values can be selected too.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query is a parsed expression
+type Query struct {
+	paths [][]step
+}
+
+// step selects values from one level of the document
+type step struct {
+	keys     []string
+	index    *int
+	wildcard bool
+}
+
+// Parse reads an expression
+func Parse(expr string) (*Query, error) {
+	q := &Query{}
+	p := &parser{s: strings.TrimSpace(expr)}
+	for {
+		path, err := p.path()
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %q: %w", expr, err)
+		}
+		q.paths = append(q.paths, path)
+		if p.done() {
+			return q, nil
+		}
+		p.pos++ // the "," between paths
+	}
+}
+
+// Single reports whether the query selects at most one value
+func (q *Query) Single() bool {
+	if len(q.paths) > 1 {
+		return false
+	}
+	for _, s := range q.paths[0] {
+		if s.wildcard || len(s.keys) > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Eval returns the values the query selects from doc, in order. Keys or
+// indexes that don't exist select nothing.
+func (q *Query) Eval(doc interface{}) []interface{} {
+	var results []interface{}
+	for _, path := range q.paths {
+		values := []interface{}{doc}
+		for _, s := range path {
+			var next []interface{}
+			for _, v := range values {
+				next = append(next, s.apply(v)...)
+			}
+			values = next
+		}
+		results = append(results, values...)
+	}
+	return results
+}
+
+func (s step) apply(v interface{}) []interface{} {
+	v = decodeJSONString(v)
+
+	switch {
+	case s.wildcard:
+		switch t := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(t))
+			for k := range t {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]interface{}, len(keys))
+			for i, k := range keys {
+				out[i] = t[k]
+			}
+			return out
+		case []interface{}:
+			return t
+		}
+	case s.index != nil:
+		if arr, ok := v.([]interface{}); ok {
+			i := *s.index
+			if i < 0 {
+				i += len(arr)
+			}
+			if i >= 0 && i < len(arr) {
+				return []interface{}{arr[i]}
+			}
+		}
+	default:
+		if m, ok := v.(map[string]interface{}); ok {
+			var out []interface{}
+			for _, k := range s.keys {
+				if value, ok := m[k]; ok {
+					out = append(out, value)
+				}
+			}
+			return out
+		}
+	}
+	return nil
+}
+
+// decodeJSONString returns what a string holding a JSON object or array
+// encodes, and any other value as is
+func decodeJSONString(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return v
+	}
+
+	var decoded interface{}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil || dec.More() {
+		return v
+	}
+	return decoded
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// path reads steps up to the end or a "," between paths
+func (p *parser) path() ([]step, error) {
+	root := p.peek() == '$'
+	if root {
+		p.pos++
+	}
+
+	var steps []step
+	first := true
+	for !p.done() && p.peek() != ',' {
+		switch c := p.peek(); {
+		case c == '.':
+			p.pos++
+			s, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case c == '[':
+			s, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		case first:
+			// A leading key doesn't need a dot: data.user
+			s, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", c, p.pos)
+		}
+		first = false
+	}
+	if len(steps) == 0 && !root {
+		return nil, fmt.Errorf("empty path at offset %d", p.pos)
+	}
+	return steps, nil
+}
+
+// name reads a bare key or "*" after a dot
+func (p *parser) name() (step, error) {
+	start := p.pos
+	for !p.done() && !strings.ContainsRune(".[],", rune(p.peek())) {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+	switch name {
+	case "":
+		return step{}, fmt.Errorf("missing key at offset %d", start)
+	case "*":
+		return step{wildcard: true}, nil
+	}
+	return step{keys: []string{name}}, nil
+}
+
+// bracket reads [n], [*] or ['key', ...]
+func (p *parser) bracket() (step, error) {
+	start := p.pos
+	p.pos++ // [
+	end := strings.IndexByte(p.s[p.pos:], ']')
+	if end < 0 {
+		return step{}, fmt.Errorf("missing ] for [ at offset %d", start)
+	}
+
+	inner := strings.TrimSpace(p.s[p.pos : p.pos+end])
+	switch {
+	case inner == "*":
+		p.pos += end + 1
+		return step{wildcard: true}, nil
+	case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`):
+		return p.quotedKeys(start)
+	}
+
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, fmt.Errorf("invalid index %q at offset %d", inner, start)
+	}
+	p.pos += end + 1
+	return step{index: &n}, nil
+}
+
+// quotedKeys reads 'a', "b"] after the opening bracket
+func (p *parser) quotedKeys(start int) (step, error) {
+	var s step
+	for {
+		for p.peek() == ' ' {
+			p.pos++
+		}
+		quote := p.peek()
+		if quote != '\'' && quote != '"' {
+			return step{}, fmt.Errorf("expected a quoted key at offset %d", p.pos)
+		}
+		end := strings.IndexByte(p.s[p.pos+1:], quote)
+		if end < 0 {
+			return step{}, fmt.Errorf("missing closing quote in [ at offset %d", start)
+		}
+		s.keys = append(s.keys, p.s[p.pos+1:p.pos+1+end])
+		p.pos += end + 2
+
+		for p.peek() == ' ' {
+			p.pos++
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return s, nil
+		default:
+			return step{}, fmt.Errorf("expected , or ] at offset %d", p.pos)
+		}
+	}
+}
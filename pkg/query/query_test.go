@@ -0,0 +1,73 @@
+package query
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestEval(t *testing.T) {
+	doc := decode(t, `{
+		"data": {
+			"user": "app",
+			"api.key": "k",
+			"hosts": ["a", "b", "c"],
+			"tls": {"cert": "C", "key": "K"},
+			"config": "{\"db\": {\"port\": 5432}}"
+		},
+		"metadata": {"version": 3}
+	}`)
+
+	tests := []struct {
+		expr   string
+		want   []interface{}
+		single bool
+	}{
+		{"$.data.user", []interface{}{"app"}, true},
+		{"data.user", []interface{}{"app"}, true},
+		{".data['api.key']", []interface{}{"k"}, true},
+		{".data.hosts[1]", []interface{}{"b"}, true},
+		{".data.hosts[-1]", []interface{}{"c"}, true},
+		{".data.hosts[*]", []interface{}{"a", "b", "c"}, false},
+		{".data.tls.*", []interface{}{"C", "K"}, false},
+		{`.data["user", "api.key"]`, []interface{}{"app", "k"}, false},
+		{".data.user,.metadata.version", []interface{}{"app", json.Number("3")}, false},
+		{".data.config.db.port", []interface{}{json.Number("5432")}, true},
+		{".data.missing", nil, true},
+		{".data.hosts[9]", nil, true},
+		{"$", []interface{}{doc}, true},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := q.Eval(doc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+		if q.Single() != tt.single {
+			t.Errorf("Parse(%q).Single() = %t, want %t", tt.expr, q.Single(), tt.single)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", ".", ".data.", ".data[", ".data[x]", ".data['a'", ".data['a' 'b']", ".a,,.b", ".a]"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package render renders text/template files with functions that look up
+// Vault secrets
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+)
+
+// SecretReader reads the latest version of a secret; *vault.Client is one
+type SecretReader interface {
+	GetSecret(path string) (*vault.Secret, error)
+}
+
+// Renderer executes templates, reading each secret path at most once
+type Renderer struct {
+	reader  SecretReader
+	secrets map[string]*vault.Secret
+	errors  map[string]error
+}
+
+// New returns a renderer that reads secrets through reader
+func New(reader SecretReader) *Renderer {
+	return &Renderer{
+		reader:  reader,
+		secrets: make(map[string]*vault.Secret),
+		errors:  make(map[string]error),
+	}
+}
+
+// Funcs returns the template functions:
+//
+//	secret "path"          the secret's data as a map
+//	secret "path" "key"    a single value; a missing key is an error
+//	env "NAME"             an environment variable, "" if unset
+//	base64 value           standard base64 encoding
+//	toJSON value           JSON encoding
+//
+// Binary values stored behind values.BinaryPrefix are given as the bytes they
+// stand for, so "base64" encodes the original content.
+func (r *Renderer) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"secret": r.secret,
+		"env":    os.Getenv,
+		"base64": func(v interface{}) string {
+			return base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(v)))
+		},
+		"toJSON": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// Render parses text and writes the result to w. Nothing is written unless
+// the whole template renders.
+func (r *Renderer) Render(w io.Writer, name, text string) error {
+	tmpl, err := template.New(name).Funcs(r.Funcs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// Paths returns how many distinct secrets were read
+func (r *Renderer) Paths() int {
+	return len(r.secrets) + len(r.errors)
+}
+
+func (r *Renderer) secret(path string, key ...string) (interface{}, error) {
+	if len(key) > 1 {
+		return nil, fmt.Errorf("secret takes a path and at most one key")
+	}
+
+	secret, err := r.read(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) == 0 {
+		data := make(map[string]interface{}, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = decodeBinary(v)
+		}
+		return data, nil
+	}
+
+	value, ok := secret.Data[key[0]]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %q", path, key[0])
+	}
+	return decodeBinary(value), nil
+}
+
+// decodeBinary turns a marked binary value into the string of its bytes
+func decodeBinary(v interface{}) interface{} {
+	if b, ok := values.Binary(v); ok {
+		return string(b)
+	}
+	return v
+}
+
+func (r *Renderer) read(path string) (*vault.Secret, error) {
+	if secret, ok := r.secrets[path]; ok {
+		return secret, nil
+	}
+	if err, ok := r.errors[path]; ok {
+		return nil, err
+	}
+
+	secret, err := r.reader.GetSecret(path)
+	if err != nil {
+		r.errors[path] = err
+		return nil, err
+	}
+	r.secrets[path] = secret
+	return secret, nil
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/values"
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+)
+
+// fakeReader serves fixed secrets and counts reads per path
+type fakeReader struct {
+	secrets map[string]map[string]interface{}
+	reads   map[string]int
+}
+
+func (f *fakeReader) GetSecret(path string) (*vault.Secret, error) {
+	f.reads[path]++
+	data, ok := f.secrets[path]
+	if !ok {
+		return nil, fmt.Errorf("%w at %s", vault.ErrSecretNotFound, path)
+	}
+	return &vault.Secret{Path: path, Data: data}, nil
+}
+
+func TestRender(t *testing.T) {
+	reader := &fakeReader{
+		secrets: map[string]map[string]interface{}{
+			"secret/app/db": {"user": "app", "password": "p@ss"},
+		},
+		reads: make(map[string]int),
+	}
+	t.Setenv("APP_ENV", "dev")
+
+	tmpl := `env={{ env "APP_ENV" }}
+user={{ secret "secret/app/db" "user" }}
+password={{ secret "secret/app/db" "password" | base64 }}
+all={{ secret "secret/app/db" | toJSON }}
+`
+	var out bytes.Buffer
+	if err := New(reader).Render(&out, "test", tmpl); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+
+	want := `env=dev
+user=app
+password=cEBzcw==
+all={"password":"p@ss","user":"app"}
+`
+	if out.String() != want {
+		t.Errorf("Render() =\n%s\nwant:\n%s", out.String(), want)
+	}
+	if reader.reads["secret/app/db"] != 1 {
+		t.Errorf("secret/app/db read %d times, want once", reader.reads["secret/app/db"])
+	}
+}
+
+func TestRenderBinary(t *testing.T) {
+	reader := &fakeReader{
+		secrets: map[string]map[string]interface{}{
+			"secret/app/tls": {"cert": values.FromBytes([]byte{0x30, 0x82, 0x00, 0xff})},
+		},
+		reads: make(map[string]int),
+	}
+
+	var out bytes.Buffer
+	tmpl := `{{ secret "secret/app/tls" "cert" | base64 }} {{ (secret "secret/app/tls").cert | base64 }}`
+	if err := New(reader).Render(&out, "test", tmpl); err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if want := "MIIA/w== MIIA/w=="; out.String() != want {
+		t.Errorf("Render() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	reader := &fakeReader{
+		secrets: map[string]map[string]interface{}{"secret/app": {"a": "1"}},
+		reads:   make(map[string]int),
+	}
+
+	tests := map[string]string{
+		"missing key":    `{{ secret "secret/app" "b" }}`,
+		"missing secret": `{{ secret "secret/none" "a" }}{{ secret "secret/none" "a" }}`,
+		"syntax":         `{{ secret "secret/app" `,
+	}
+	for name, tmpl := range tests {
+		var out bytes.Buffer
+		if err := New(reader).Render(&out, name, tmpl); err == nil {
+			t.Errorf("%s: Render() should fail", name)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: partial output %q written", name, out.String())
+		}
+	}
+	if reader.reads["secret/none"] != 1 {
+		t.Errorf("failed read repeated %d times, want once", reader.reads["secret/none"])
+	}
+
+	if err := New(reader).Render(&bytes.Buffer{}, "ok", `{{ secret "secret/app" "a" }}`); err != nil {
+		t.Errorf("Render() error: %v", err)
+	}
+}
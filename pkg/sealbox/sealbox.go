@@ -0,0 +1,102 @@
+// Package sealbox encrypts small blobs (tokens, secret values) with a key
+// derived from a passphrase
+package sealbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+	keyLen  = 32
+	saltLen = 16
+)
+
+// ErrDecrypt is returned when data can't be opened, usually because of a
+// wrong passphrase
+var ErrDecrypt = errors.New("failed to decrypt: wrong passphrase or corrupted data")
+
+// box is the on-disk format; byte slices are base64-encoded by encoding/json
+type box struct {
+	Version    int    `json:"v"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Seal encrypts plaintext with AES-256-GCM under a key derived from passphrase
+func Seal(passphrase string, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return json.Marshal(box{
+		Version:    1,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	})
+}
+
+// Open decrypts data produced by Seal
+func Open(passphrase string, data []byte) ([]byte, error) {
+	var b box
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse sealed data: %w", err)
+	}
+	if b.Version != 1 {
+		return nil, fmt.Errorf("unsupported sealed data version: %d", b.Version)
+	}
+
+	gcm, err := newGCM(passphrase, b.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(b.Nonce) != gcm.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	plaintext, err := gcm.Open(nil, b.Nonce, b.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
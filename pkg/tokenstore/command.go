@@ -0,0 +1,72 @@
+package tokenstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CommandStore delegates to an external helper, similar to git credential
+// helpers. The helper is run as "<command> get|store|erase <key>"; store
+// receives the entry as JSON on stdin and get prints it on stdout (nothing if
+// there is no entry). The command line goes through the shell, so it may
+// quote arguments and paths with spaces.
+type CommandStore struct {
+	Command string
+}
+
+// NewCommandStore returns a store backed by the given helper command line
+func NewCommandStore(command string) *CommandStore {
+	return &CommandStore{Command: command}
+}
+
+// Load asks the helper for the entry for addr
+func (s *CommandStore) Load(addr string) (*Entry, error) {
+	out, err := s.run("get", addr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(out, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse token helper output: %w", err)
+	}
+	return &entry, nil
+}
+
+// Save hands the entry for addr to the helper
+func (s *CommandStore) Save(addr string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+	_, err = s.run("store", addr, data)
+	return err
+}
+
+// Delete asks the helper to forget the entry for addr
+func (s *CommandStore) Delete(addr string) error {
+	_, err := s.run("erase", addr, nil)
+	return err
+}
+
+func (s *CommandStore) run(action, addr string, stdin []byte) ([]byte, error) {
+	if strings.TrimSpace(s.Command) == "" {
+		return nil, fmt.Errorf("token_command is empty")
+	}
+
+	cmd := shellCommand(s.Command, action, keyFor(addr))
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("token helper %q %s failed: %w", s.Command, action, err)
+	}
+	return out, nil
+}
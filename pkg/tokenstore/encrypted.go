@@ -0,0 +1,113 @@
+package tokenstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dautovri/ruslan-cli/pkg/sealbox"
+	"golang.org/x/term"
+)
+
+// PassphraseEnvVar supplies the encrypted-file passphrase non-interactively
+const PassphraseEnvVar = "RUSLAN_TOKEN_PASSPHRASE"
+
+// EncryptedStore is a FileStore whose files are sealed with a passphrase
+type EncryptedStore struct {
+	Dir        string
+	passphrase func() (string, error)
+	cached     string
+}
+
+// NewEncryptedStore returns an encrypted store rooted at dir. The passphrase
+// func is only called the first time a file has to be opened or sealed.
+func NewEncryptedStore(dir string, passphrase func() (string, error)) *EncryptedStore {
+	return &EncryptedStore{Dir: dir, passphrase: passphrase}
+}
+
+// Load decrypts the entry for addr
+func (s *EncryptedStore) Load(addr string) (*Entry, error) {
+	sealed, err := readEntryFile(s.path(addr))
+	if err != nil || sealed == nil {
+		return nil, err
+	}
+
+	pass, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	data, err := sealbox.Open(pass, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	return &entry, nil
+}
+
+// Save encrypts and writes the entry for addr
+func (s *EncryptedStore) Save(addr string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	pass, err := s.getPassphrase()
+	if err != nil {
+		return err
+	}
+	sealed, err := sealbox.Seal(pass, data)
+	if err != nil {
+		return err
+	}
+	return writeEntryFile(s.path(addr), sealed)
+}
+
+// Delete removes the entry for addr
+func (s *EncryptedStore) Delete(addr string) error {
+	return removeEntryFile(s.path(addr))
+}
+
+func (s *EncryptedStore) path(addr string) string {
+	return filepath.Join(s.Dir, keyFor(addr)+".enc")
+}
+
+func (s *EncryptedStore) getPassphrase() (string, error) {
+	if s.cached != "" {
+		return s.cached, nil
+	}
+	pass, err := s.passphrase()
+	if err != nil {
+		return "", err
+	}
+	if pass == "" {
+		return "", errors.New("token store passphrase is empty")
+	}
+	s.cached = pass
+	return pass, nil
+}
+
+// passphraseFromEnvOrPrompt reads RUSLAN_TOKEN_PASSPHRASE or asks on the terminal
+func passphraseFromEnvOrPrompt() (string, error) {
+	if pass := os.Getenv(PassphraseEnvVar); pass != "" {
+		return pass, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("token store passphrase required: set %s", PassphraseEnvVar)
+	}
+
+	fmt.Fprint(os.Stderr, "Token store passphrase: ")
+	pass, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(pass), nil
+}
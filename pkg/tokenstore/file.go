@@ -0,0 +1,98 @@
+package tokenstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileStore saves one 0600 JSON file per Vault address in a directory
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore returns a file store rooted at dir (usually Config.TokenFile)
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+// Load reads the entry for addr
+func (s *FileStore) Load(addr string) (*Entry, error) {
+	data, err := readEntryFile(s.path(addr))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+	return &entry, nil
+}
+
+// Save writes the entry for addr
+func (s *FileStore) Save(addr string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+	return writeEntryFile(s.path(addr), data)
+}
+
+// Delete removes the entry for addr
+func (s *FileStore) Delete(addr string) error {
+	return removeEntryFile(s.path(addr))
+}
+
+func (s *FileStore) path(addr string) string {
+	return filepath.Join(s.Dir, keyFor(addr)+".json")
+}
+
+// readEntryFile returns nil data if the file doesn't exist
+func readEntryFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	return data, nil
+}
+
+func writeEntryFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	// Write to a temp file and rename so concurrent invocations never see a partial token
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".token-*")
+	if err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+func removeEntryFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove token file: %w", err)
+	}
+	return nil
+}
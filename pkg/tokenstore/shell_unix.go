@@ -0,0 +1,12 @@
+//go:build !windows
+
+package tokenstore
+
+import "os/exec"
+
+// shellCommand runs command with sh, passing args as "$@" so they stay
+// separate words whatever they contain
+func shellCommand(command string, args ...string) *exec.Cmd {
+	shArgs := append([]string{"-c", command + ` "$@"`, "sh"}, args...)
+	return exec.Command("sh", shArgs...) // #nosec G204 -- command comes from the user config
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package tokenstore
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// shellCommand runs command with cmd.exe. args are appended as they are; the
+// actions and keys passed here never need quoting.
+func shellCommand(command string, args ...string) *exec.Cmd {
+	return exec.Command("cmd", "/C", command+" "+strings.Join(args, " ")) // #nosec G204 -- command comes from the user config
+}
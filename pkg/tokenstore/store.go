@@ -0,0 +1,124 @@
+// Package tokenstore keeps Vault tokens out of config.yaml. Entries are keyed
+// by Vault address, so environments pointing at the same Vault share a login.
+package tokenstore
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+)
+
+// Backend names accepted in the token_store config setting
+const (
+	BackendFile      = "file"
+	BackendEncrypted = "encrypted-file"
+	BackendCommand   = "command"
+)
+
+// Login methods recorded in Entry.Method
+const (
+	MethodToken    = "token"
+	MethodUserPass = "userpass"
+	MethodAppRole  = "approle"
+)
+
+// Entry is the saved login state for one Vault address
+type Entry struct {
+	Token     string    `json:"token"`
+	Method    string    `json:"method,omitempty"`
+	Renewable bool      `json:"renewable,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"` // zero for tokens that never expire
+
+	// Credentials are only kept when the user asked for re-login on expiry,
+	// e.g. role_id/secret_id or username/password
+	Credentials map[string]string `json:"credentials,omitempty"`
+}
+
+// Secure reports whether the store protects entries beyond file permissions,
+// which is required before login credentials may be saved in it
+func Secure(store Store) bool {
+	switch store.(type) {
+	case *EncryptedStore, *CommandStore:
+		return true
+	default:
+		return false
+	}
+}
+
+// Store persists entries keyed by Vault address
+type Store interface {
+	// Load returns the saved entry, or nil if there is none
+	Load(addr string) (*Entry, error)
+	Save(addr string, entry *Entry) error
+	Delete(addr string) error
+}
+
+// New returns the backend selected by cfg.TokenStore (file by default)
+func New(cfg *config.Config) (Store, error) {
+	switch cfg.TokenStore {
+	case "", BackendFile:
+		return NewFileStore(cfg.TokenFile), nil
+	case BackendEncrypted:
+		return NewEncryptedStore(cfg.TokenFile, passphraseFromEnvOrPrompt), nil
+	case BackendCommand:
+		if cfg.TokenCommand == "" {
+			return nil, fmt.Errorf("token_store is %q but token_command is not set", BackendCommand)
+		}
+		return NewCommandStore(cfg.TokenCommand), nil
+	default:
+		return nil, fmt.Errorf("unknown token_store: %s", cfg.TokenStore)
+	}
+}
+
+// MigrateConfig moves tokens still saved in config.yaml into store and strips
+// them from the file. It returns how many tokens were moved.
+func MigrateConfig(store Store) (int, error) {
+	user, err := config.LoadUser()
+	if err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for name, env := range user.Environments {
+		if env == nil || env.Token == "" {
+			continue
+		}
+		if env.VaultAddr == "" {
+			return moved, fmt.Errorf("can't migrate token for environment %s: vault_addr is not set", name)
+		}
+
+		// A token already in the store is newer than the one left in the config
+		existing, err := store.Load(env.VaultAddr)
+		if err != nil {
+			return moved, err
+		}
+		if existing == nil {
+			if err := store.Save(env.VaultAddr, &Entry{Token: env.Token}); err != nil {
+				return moved, fmt.Errorf("failed to migrate token for environment %s: %w", name, err)
+			}
+		}
+
+		env.Token = ""
+		moved++
+	}
+
+	if moved == 0 {
+		return 0, nil
+	}
+	return moved, user.Save()
+}
+
+// keyFor turns a Vault address into a file-name-safe key
+func keyFor(addr string) string {
+	addr = strings.ToLower(strings.TrimRight(addr, "/"))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, addr)
+}
@@ -0,0 +1,160 @@
+package tokenstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/sealbox"
+)
+
+func TestFileStore(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "tokens"))
+	addr := "https://vault-dev.example.com/"
+
+	entry, err := store.Load(addr)
+	if err != nil || entry != nil {
+		t.Fatalf("Load() on empty store = %v, %v; want nil, nil", entry, err)
+	}
+
+	if err := store.Save(addr, &Entry{Token: "s.dev"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	info, err := os.Stat(store.path(addr))
+	if err != nil {
+		t.Fatalf("token file missing: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+
+	entry, err = store.Load("https://vault-dev.example.com")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if entry == nil || entry.Token != "s.dev" {
+		t.Errorf("Load() = %+v, want token s.dev", entry)
+	}
+
+	if err := store.Delete(addr); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if entry, _ := store.Load(addr); entry != nil {
+		t.Errorf("Load() after Delete() = %+v, want nil", entry)
+	}
+}
+
+func TestEncryptedStore(t *testing.T) {
+	dir := t.TempDir()
+	addr := "https://vault.example.com"
+	pass := func(p string) func() (string, error) {
+		return func() (string, error) { return p, nil }
+	}
+
+	store := NewEncryptedStore(dir, pass("correct horse"))
+	if err := store.Save(addr, &Entry{Token: "s.prod"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	raw, err := os.ReadFile(store.path(addr))
+	if err != nil {
+		t.Fatalf("token file missing: %v", err)
+	}
+	if string(raw) == "" || filepath.Ext(store.path(addr)) != ".enc" {
+		t.Fatalf("unexpected token file %s", store.path(addr))
+	}
+
+	entry, err := NewEncryptedStore(dir, pass("correct horse")).Load(addr)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if entry.Token != "s.prod" {
+		t.Errorf("Load() token = %s, want s.prod", entry.Token)
+	}
+
+	_, err = NewEncryptedStore(dir, pass("wrong")).Load(addr)
+	if !errors.Is(err, sealbox.ErrDecrypt) {
+		t.Errorf("Load() with wrong passphrase error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir := t.TempDir()
+	config.SetPath(filepath.Join(dir, "config.yaml"))
+	t.Cleanup(func() { config.SetPath("") })
+
+	cfg := config.DefaultConfig()
+	cfg.Environments["dev"].Token = "s.legacy"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	store := NewFileStore(filepath.Join(dir, "tokens"))
+	moved, err := MigrateConfig(store)
+	if err != nil {
+		t.Fatalf("MigrateConfig() error: %v", err)
+	}
+	if moved != 1 {
+		t.Errorf("MigrateConfig() moved %d tokens, want 1", moved)
+	}
+
+	entry, err := store.Load(cfg.Environments["dev"].VaultAddr)
+	if err != nil || entry == nil || entry.Token != "s.legacy" {
+		t.Errorf("migrated entry = %+v, %v; want token s.legacy", entry, err)
+	}
+
+	reloaded, err := config.LoadUser()
+	if err != nil {
+		t.Fatalf("LoadUser() error: %v", err)
+	}
+	if reloaded.Environments["dev"].Token != "" {
+		t.Error("Expected token to be stripped from config.yaml")
+	}
+
+	if moved, err := MigrateConfig(store); err != nil || moved != 0 {
+		t.Errorf("second MigrateConfig() = %d, %v; want 0, nil", moved, err)
+	}
+}
+
+func TestCommandStoreQuoting(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("helper is a shell script")
+	}
+
+	dir := filepath.Join(t.TempDir(), "token helper")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	helper := filepath.Join(dir, "helper.sh")
+	script := `#!/bin/sh
+# $1 is the directory given in token_command, then action and key
+case "$2" in
+store) cat > "$1/$3" ;;
+get) cat "$1/$3" 2>/dev/null || true ;;
+erase) rm -f "$1/$3" ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewCommandStore(`"` + helper + `" "` + dir + `"`)
+	addr := "https://vault.example.com"
+	if err := store.Save(addr, &Entry{Token: "s.cmd"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	entry, err := store.Load(addr)
+	if err != nil || entry == nil || entry.Token != "s.cmd" {
+		t.Fatalf("Load() = %+v, %v; want token s.cmd", entry, err)
+	}
+	if err := store.Delete(addr); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if entry, _ := store.Load(addr); entry != nil {
+		t.Errorf("Load() after Delete() = %+v, want nil", entry)
+	}
+}
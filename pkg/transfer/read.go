@@ -0,0 +1,241 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// Document is an exported set of secrets. Only the current data is read;
+// metadata and older versions in the document are ignored.
+type Document struct {
+	// Root is the folder the secrets were exported from, if recorded
+	Root string
+	// Secrets maps paths relative to the root to their data. A dotenv file
+	// without "# secret:" markers is a single secret with the path "".
+	Secrets map[string]map[string]interface{}
+}
+
+type documentFile struct {
+	Root    string `json:"root" yaml:"root"`
+	Secrets map[string]struct {
+		Data map[string]interface{} `json:"data" yaml:"data"`
+	} `json:"secrets" yaml:"secrets"`
+}
+
+// Read parses a document written by Writer, or a plain dotenv file
+func Read(r io.Reader, format string) (*Document, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if format == FormatDotenv {
+		return readDotenv(r)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file documentFile
+	if format == FormatJSON {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s document: %w", format, err)
+	}
+
+	doc := &Document{Root: file.Root, Secrets: make(map[string]map[string]interface{}, len(file.Secrets))}
+	for rel, secret := range file.Secrets {
+		if secret.Data == nil {
+			return nil, fmt.Errorf("secret %q has no data", rel)
+		}
+		// YAML numbers decode as int and float64, but Vault returns json.Number
+		data, err := vault.NormalizeData(secret.Data)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", rel, err)
+		}
+		doc.Secrets[rel] = data
+	}
+	return doc, nil
+}
+
+// ReadData parses the data of a single secret: a JSON or YAML map of keys to
+// values, or a dotenv file without "# secret:" markers
+func ReadData(r io.Reader, format string) (map[string]interface{}, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if format == FormatDotenv {
+		doc, err := readDotenv(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Secrets) > 1 || (len(doc.Secrets) == 1 && doc.Secrets[""] == nil) {
+			return nil, fmt.Errorf("dotenv file holds several secrets; use secrets import")
+		}
+		data := doc.Secrets[""]
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		return data, nil
+	}
+
+	var data map[string]interface{}
+	var err error
+	if format == FormatJSON {
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		err = dec.Decode(&data)
+	} else {
+		err = yaml.NewDecoder(r).Decode(&data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s data: %w", format, err)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s data is empty", format)
+	}
+	return vault.NormalizeData(data)
+}
+
+// readDotenv reads KEY=value lines, starting a new secret at each
+// "# secret: <path>" comment. Values of the keys listed in a "# json: <keys>"
+// comment are decoded as JSON.
+func readDotenv(r io.Reader) (*Document, error) {
+	doc := &Document{Secrets: make(map[string]map[string]interface{})}
+	current := ""
+	jsonKeys := make(map[string][]string)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, dotenvRootPrefix):
+			doc.Root = strings.TrimSpace(strings.TrimPrefix(line, dotenvRootPrefix))
+			continue
+		case strings.HasPrefix(line, dotenvSecretPrefix):
+			current = strings.TrimSpace(strings.TrimPrefix(line, dotenvSecretPrefix))
+			if _, ok := doc.Secrets[current]; ok {
+				return nil, fmt.Errorf("line %d: secret %q appears twice", n, current)
+			}
+			doc.Secrets[current] = make(map[string]interface{})
+			continue
+		case strings.HasPrefix(line, dotenvJSONPrefix):
+			for _, key := range strings.Split(strings.TrimPrefix(line, dotenvJSONPrefix), ",") {
+				jsonKeys[current] = append(jsonKeys[current], strings.TrimSpace(key))
+			}
+			continue
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		}
+
+		key, value, err := parseDotenvLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if doc.Secrets[current] == nil {
+			doc.Secrets[current] = make(map[string]interface{})
+		}
+		doc.Secrets[current][key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for path, keys := range jsonKeys {
+		for _, key := range keys {
+			s, ok := doc.Secrets[path][key].(string)
+			if !ok {
+				continue
+			}
+			dec := json.NewDecoder(strings.NewReader(s))
+			dec.UseNumber()
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				return nil, fmt.Errorf("secret %q: invalid JSON value for %s: %w", path, key, err)
+			}
+			doc.Secrets[path][key] = v
+		}
+	}
+	return doc, nil
+}
+
+// parseDotenvLine parses KEY=value with an optional "export " prefix. Values
+// may be double-quoted with backslash escapes, single-quoted literally or
+// unquoted, where a " #" starts a comment.
+func parseDotenvLine(line string) (string, string, error) {
+	line = strings.TrimPrefix(line, "export ")
+	key, value, ok := strings.Cut(line, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("expected KEY=value, got %q", line)
+	}
+	value = strings.TrimSpace(value)
+
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := unquoteDouble(value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		return key, unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", "", fmt.Errorf("invalid value for %s: missing closing quote", key)
+		}
+		return key, value[1:end], nil
+	default:
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return key, value, nil
+	}
+}
+
+// unquoteDouble reads a double-quoted value, which may be followed by a comment
+func unquoteDouble(s string) (string, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			if rest := strings.TrimSpace(s[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected text after closing quote: %q", rest)
+			}
+			return b.String(), nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", fmt.Errorf("missing closing quote")
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				// \" \\ \$ and unknown escapes stand for the character itself
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("missing closing quote")
+}
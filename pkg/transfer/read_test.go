@@ -0,0 +1,147 @@
+package transfer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+)
+
+func TestReadRoundTrip(t *testing.T) {
+	secrets := map[string]*vault.ExportedSecret{
+		"api":      {Data: map[string]interface{}{"token": "abc", "note": "tab\there"}, Version: 3},
+		"db/creds": {Data: map[string]interface{}{"password": `p$ss "quoted" \ back` + "\nline"}},
+	}
+	order := []string{"api", "db/creds"}
+
+	for _, format := range []string{FormatJSON, FormatYAML, FormatDotenv} {
+		out := writeDocument(t, format, secrets, order)
+		doc, err := Read(strings.NewReader(out), format)
+		if err != nil {
+			t.Fatalf("Read(%s) error: %v\n%s", format, err, out)
+		}
+		if doc.Root != "secret/app/" {
+			t.Errorf("%s root = %q, want secret/app/", format, doc.Root)
+		}
+		for _, rel := range order {
+			if !reflect.DeepEqual(doc.Secrets[rel], secrets[rel].Data) {
+				t.Errorf("%s secret %s = %v, want %v", format, rel, doc.Secrets[rel], secrets[rel].Data)
+			}
+		}
+	}
+}
+
+func TestReadJSONKeepsNumbers(t *testing.T) {
+	doc, err := Read(strings.NewReader(`{"secrets": {"app": {"data": {"port": 8080}}}}`), FormatJSON)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if got := doc.Secrets["app"]["port"]; got != json.Number("8080") {
+		t.Errorf("port = %#v, want json.Number(8080)", got)
+	}
+}
+
+func TestReadYAMLNumbersMatchVault(t *testing.T) {
+	secrets := map[string]*vault.ExportedSecret{
+		"app": {Data: map[string]interface{}{"port": json.Number("5432"), "ratio": json.Number("0.5"), "name": "app"}},
+	}
+	out := writeDocument(t, FormatYAML, secrets, []string{"app"})
+
+	doc, err := Read(strings.NewReader(out), FormatYAML)
+	if err != nil {
+		t.Fatalf("Read() error: %v\n%s", err, out)
+	}
+	if changes := vault.DiffData(secrets["app"].Data, doc.Secrets["app"]); len(changes) > 0 {
+		t.Errorf("exported and re-read YAML differs: %+v", changes)
+	}
+
+	data, err := ReadData(strings.NewReader("port: 5432\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("ReadData() error: %v", err)
+	}
+	if got := data["port"]; got != json.Number("5432") {
+		t.Errorf("ReadData() port = %#v, want json.Number(5432)", got)
+	}
+}
+
+func TestReadDotenvKeepsTypes(t *testing.T) {
+	secrets := map[string]*vault.ExportedSecret{
+		"app": {Data: map[string]interface{}{
+			"port":    json.Number("5432"),
+			"debug":   true,
+			"tags":    []interface{}{"a", "b"},
+			"limits":  map[string]interface{}{"cpu": json.Number("0.5")},
+			"version": "1.2",
+		}},
+	}
+	out := writeDocument(t, FormatDotenv, secrets, []string{"app"})
+
+	doc, err := Read(strings.NewReader(out), FormatDotenv)
+	if err != nil {
+		t.Fatalf("Read() error: %v\n%s", err, out)
+	}
+	if changes := vault.DiffData(secrets["app"].Data, doc.Secrets["app"]); len(changes) > 0 {
+		t.Errorf("exported and re-read dotenv differs: %+v\n%s", changes, out)
+	}
+	if got := doc.Secrets["app"]["version"]; got != "1.2" {
+		t.Errorf("string value = %#v, want \"1.2\"", got)
+	}
+}
+
+func TestReadPlainDotenv(t *testing.T) {
+	input := `# database settings
+export DB_HOST=localhost # local only
+DB_USER='app'
+DB_PASS="s3cr\"t"
+EMPTY=
+`
+	doc, err := Read(strings.NewReader(input), FormatDotenv)
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+
+	want := map[string]map[string]interface{}{
+		"": {"DB_HOST": "localhost", "DB_USER": "app", "DB_PASS": `s3cr"t`, "EMPTY": ""},
+	}
+	if !reflect.DeepEqual(doc.Secrets, want) {
+		t.Errorf("Secrets = %v, want %v", doc.Secrets, want)
+	}
+
+	for _, bad := range []string{"NOEQUALS", `KEY="unterminated`, `KEY="a" trailing`} {
+		if _, err := Read(strings.NewReader(bad), FormatDotenv); err == nil {
+			t.Errorf("Read(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReadData(t *testing.T) {
+	want := map[string]interface{}{"user": "app", "password": "s3cret"}
+	inputs := map[string]string{
+		FormatJSON:   `{"user": "app", "password": "s3cret"}`,
+		FormatYAML:   "user: app\npassword: s3cret\n",
+		FormatDotenv: "user=app\npassword=\"s3cret\"\n",
+	}
+	for format, input := range inputs {
+		got, err := ReadData(strings.NewReader(input), format)
+		if err != nil {
+			t.Errorf("ReadData(%s) error: %v", format, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadData(%s) = %v, want %v", format, got, want)
+		}
+	}
+
+	bad := map[string]string{
+		FormatJSON:   `["not", "a", "map"]`,
+		FormatYAML:   "",
+		FormatDotenv: "# secret: a\nX=1\n# secret: b\nY=2\n",
+	}
+	for format, input := range bad {
+		if _, err := ReadData(strings.NewReader(input), format); err == nil {
+			t.Errorf("ReadData(%s, %q) should fail", format, input)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package transfer reads and writes exported secrets as JSON, YAML or dotenv
+// documents keyed by path relative to the exported folder
+package transfer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Document formats
+const (
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+	FormatDotenv = "dotenv"
+)
+
+// dotenvSecretPrefix marks the start of a secret in a dotenv document
+const dotenvSecretPrefix = "# secret: "
+
+// dotenvRootPrefix records the exported folder in a dotenv document
+const dotenvRootPrefix = "# root: "
+
+// dotenvJSONPrefix lists the keys of a secret whose dotenv values are JSON,
+// so that numbers, booleans and objects are read back with their types
+const dotenvJSONPrefix = "# json: "
+
+// FormatFromPath guesses the format from a file name, or returns "" if the
+// extension isn't known
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".env":
+		return FormatDotenv
+	}
+	if strings.HasPrefix(filepath.Base(path), ".env") {
+		return FormatDotenv
+	}
+	return ""
+}
+
+// CheckFormat returns an error for unsupported formats
+func CheckFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatYAML, FormatDotenv:
+		return nil
+	}
+	return fmt.Errorf("unsupported format %q (use json, yaml or dotenv)", format)
+}
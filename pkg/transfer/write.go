@@ -0,0 +1,268 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"gopkg.in/yaml.v3"
+)
+
+// Writer writes a document one secret at a time, so an export never has to
+// hold more than one secret in memory
+type Writer interface {
+	// WriteSecret appends a secret under its path relative to the root
+	WriteSecret(rel string, secret *vault.ExportedSecret) error
+	// Close finishes the document; it doesn't close the underlying writer
+	Close() error
+}
+
+// NewWriter starts a document for the secrets below root
+func NewWriter(w io.Writer, format, root string) (Writer, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatYAML:
+		return &yamlWriter{w: w, root: root}, nil
+	case FormatDotenv:
+		return &dotenvWriter{w: w, root: root}, nil
+	default:
+		return &jsonWriter{w: w, root: root}, nil
+	}
+}
+
+type jsonWriter struct {
+	w     io.Writer
+	root  string
+	count int
+}
+
+func (j *jsonWriter) WriteSecret(rel string, secret *vault.ExportedSecret) error {
+	var buf bytes.Buffer
+	if j.count == 0 {
+		root, err := json.Marshal(j.root)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "{\n  \"root\": %s,\n  \"secrets\": {\n", root)
+	} else {
+		buf.WriteString(",\n")
+	}
+	j.count++
+
+	key, err := json.Marshal(rel)
+	if err != nil {
+		return err
+	}
+	value, err := json.MarshalIndent(secret, "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", rel, err)
+	}
+	fmt.Fprintf(&buf, "    %s: %s", key, value)
+
+	_, err = j.w.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.count == 0 {
+		root, err := json.Marshal(j.root)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(j.w, "{\n  \"root\": %s,\n  \"secrets\": {}\n}\n", root)
+		return err
+	}
+	_, err := io.WriteString(j.w, "\n  }\n}\n")
+	return err
+}
+
+type yamlWriter struct {
+	w     io.Writer
+	root  string
+	count int
+}
+
+func (y *yamlWriter) header(secrets string) ([]byte, error) {
+	root, err := yaml.Marshal(map[string]string{"root": y.root})
+	if err != nil {
+		return nil, err
+	}
+	return append(root, secrets...), nil
+}
+
+func (y *yamlWriter) WriteSecret(rel string, secret *vault.ExportedSecret) error {
+	var buf bytes.Buffer
+	if y.count == 0 {
+		header, err := y.header("secrets:\n")
+		if err != nil {
+			return err
+		}
+		buf.Write(header)
+	}
+	y.count++
+
+	var entry bytes.Buffer
+	enc := yaml.NewEncoder(&entry)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{rel: yamlSecret(secret)}); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", rel, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(entry.String(), "\n") {
+		if line != "" {
+			buf.WriteString("  " + line)
+		}
+	}
+
+	_, err := y.w.Write(buf.Bytes())
+	return err
+}
+
+func (y *yamlWriter) Close() error {
+	if y.count > 0 {
+		return nil
+	}
+	header, err := y.header("secrets: {}\n")
+	if err != nil {
+		return err
+	}
+	_, err = y.w.Write(header)
+	return err
+}
+
+// dotenvWriter writes KEY="value" lines, starting each secret with a
+// "# secret: <path>" comment. Metadata is reduced to a version comment and
+// older versions are left out.
+type dotenvWriter struct {
+	w     io.Writer
+	root  string
+	count int
+}
+
+func (d *dotenvWriter) WriteSecret(rel string, secret *vault.ExportedSecret) error {
+	var buf bytes.Buffer
+	if d.count == 0 {
+		buf.WriteString(dotenvRootPrefix + d.root + "\n")
+	}
+	d.count++
+
+	buf.WriteString("\n" + dotenvSecretPrefix + rel + "\n")
+	if secret.Version > 0 {
+		fmt.Fprintf(&buf, "# version: %d\n", secret.Version)
+	}
+
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var jsonKeys []string
+	for _, key := range keys {
+		if strings.ContainsAny(key, "=\n") {
+			return fmt.Errorf("key %q in %s can't be written as dotenv", key, rel)
+		}
+		if _, ok := secret.Data[key].(string); !ok {
+			if strings.Contains(key, ",") {
+				return fmt.Errorf("key %q in %s holds a non-string value and can't be written as dotenv", key, rel)
+			}
+			jsonKeys = append(jsonKeys, key)
+		}
+	}
+	if len(jsonKeys) > 0 {
+		buf.WriteString(dotenvJSONPrefix + strings.Join(jsonKeys, ", ") + "\n")
+	}
+
+	for _, key := range keys {
+		value, err := dotenvValue(secret.Data[key])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s in %s: %w", key, rel, err)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", key, value)
+	}
+
+	_, err := d.w.Write(buf.Bytes())
+	return err
+}
+
+func (d *dotenvWriter) Close() error {
+	if d.count == 0 {
+		_, err := io.WriteString(d.w, dotenvRootPrefix+d.root+"\n")
+		return err
+	}
+	return nil
+}
+
+// dotenvValue double-quotes a value, writing anything but strings as JSON
+func dotenvValue(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		s = string(data)
+	}
+
+	return `"` + dotenvEscaper.Replace(s) + `"`, nil
+}
+
+// dotenvEscaper escapes double-quoted values; "$" is escaped too because most
+// dotenv loaders expand variables in double quotes
+var dotenvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"$", `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// yamlSecret converts the json.Number values of Vault responses, which YAML
+// would otherwise write as strings
+func yamlSecret(secret *vault.ExportedSecret) *vault.ExportedSecret {
+	converted := *secret
+	converted.Data = yamlNumbers(secret.Data).(map[string]interface{})
+	if secret.Versions != nil {
+		converted.Versions = make(map[int]map[string]interface{}, len(secret.Versions))
+		for version, data := range secret.Versions {
+			converted.Versions[version] = yamlNumbers(data).(map[string]interface{})
+		}
+	}
+	return &converted
+}
+
+func yamlNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[key] = yamlNumbers(value)
+		}
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(v))
+		for i, value := range v {
+			converted[i] = yamlNumbers(value)
+		}
+		return converted
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault"
+	"gopkg.in/yaml.v3"
+)
+
+func writeDocument(t *testing.T, format string, secrets map[string]*vault.ExportedSecret, order []string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, "secret/app/")
+	if err != nil {
+		t.Fatalf("NewWriter(%s) error: %v", format, err)
+	}
+	for _, rel := range order {
+		if err := w.WriteSecret(rel, secrets[rel]); err != nil {
+			t.Fatalf("WriteSecret(%s) error: %v", rel, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestWriters(t *testing.T) {
+	secrets := map[string]*vault.ExportedSecret{
+		"api":      {Data: map[string]interface{}{"token": "abc", "port": json.Number("8080")}, Version: 2},
+		"db/creds": {Data: map[string]interface{}{"password": "p$ss \"quoted\"\nline"}},
+	}
+	order := []string{"api", "db/creds"}
+
+	type document struct {
+		Root    string                            `json:"root" yaml:"root"`
+		Secrets map[string]map[string]interface{} `json:"secrets" yaml:"secrets"`
+	}
+
+	var fromJSON document
+	out := writeDocument(t, FormatJSON, secrets, order)
+	if err := json.Unmarshal([]byte(out), &fromJSON); err != nil {
+		t.Fatalf("JSON output doesn't parse: %v\n%s", err, out)
+	}
+	if fromJSON.Root != "secret/app/" || len(fromJSON.Secrets) != 2 {
+		t.Errorf("JSON document = %+v", fromJSON)
+	}
+
+	var fromYAML document
+	out = writeDocument(t, FormatYAML, secrets, order)
+	if err := yaml.Unmarshal([]byte(out), &fromYAML); err != nil {
+		t.Fatalf("YAML output doesn't parse: %v\n%s", err, out)
+	}
+	data, _ := fromYAML.Secrets["db/creds"]["data"].(map[string]interface{})
+	if data["password"] != "p$ss \"quoted\"\nline" {
+		t.Errorf("YAML password = %q\n%s", data["password"], out)
+	}
+	data, _ = fromYAML.Secrets["api"]["data"].(map[string]interface{})
+	if data["port"] != 8080 {
+		t.Errorf("YAML port = %#v, want 8080\n%s", data["port"], out)
+	}
+
+	want := `# root: secret/app/
+
+# secret: api
+# version: 2
+# json: port
+port="8080"
+token="abc"
+
+# secret: db/creds
+password="p\$ss \"quoted\"\nline"
+`
+	if out := writeDocument(t, FormatDotenv, secrets, order); out != want {
+		t.Errorf("dotenv output:\n%s\nwant:\n%s", out, want)
+	}
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		var empty document
+		out := writeDocument(t, format, nil, nil)
+		if err := yaml.Unmarshal([]byte(out), &empty); err != nil || empty.Root != "secret/app/" || len(empty.Secrets) != 0 {
+			t.Errorf("empty %s document = %q (%v)", format, out, err)
+		}
+	}
+}
+
+func TestDotenvValue(t *testing.T) {
+	tests := map[string]string{
+		"café":         `"café"`,
+		"a\tb\\c":      `"a\tb\\c"`,
+		`say "$HOME"`:  `"say \"\$HOME\""`,
+		"line1\r\nend": `"line1\r\nend"`,
+	}
+	for in, want := range tests {
+		if got, err := dotenvValue(in); err != nil || got != want {
+			t.Errorf("dotenvValue(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInputData(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	jsonFile := write("app.json", `{"user": "app", "password": "from-file"}`)
+	yamlFile := write("app.yaml", "user: app\npassword: from-file\n")
+	envFile := write("app.env", "user=app\npassword=from-file\n")
+	extless := write("app", "user: app\n")
+
+	tests := []struct {
+		name  string
+		input Input
+		stdin string
+		want  map[string]interface{}
+	}{
+		{
+			name:  "pairs",
+			input: Input{Args: []string{"user=app", "password=p"}},
+			want:  map[string]interface{}{"user": "app", "password": "p"},
+		},
+		{
+			name:  "json file",
+			input: Input{File: jsonFile},
+			want:  map[string]interface{}{"user": "app", "password": "from-file"},
+		},
+		{
+			name:  "yaml file",
+			input: Input{File: yamlFile},
+			want:  map[string]interface{}{"user": "app", "password": "from-file"},
+		},
+		{
+			name:  "dotenv file",
+			input: Input{File: envFile},
+			want:  map[string]interface{}{"user": "app", "password": "from-file"},
+		},
+		{
+			name:  "explicit format",
+			input: Input{File: extless, FileFormat: "yaml"},
+			want:  map[string]interface{}{"user": "app"},
+		},
+		{
+			name:  "pairs override file",
+			input: Input{File: jsonFile, Args: []string{"password=override", "debug=true"}},
+			want:  map[string]interface{}{"user": "app", "password": "override", "debug": "true"},
+		},
+		{
+			name:  "file from stdin with pairs",
+			input: Input{File: "-", FileFormat: "dotenv", Args: []string{"debug=true"}},
+			stdin: "user=app\n",
+			want:  map[string]interface{}{"user": "app", "debug": "true"},
+		},
+		{
+			name:  "pair from stdin with file",
+			input: Input{File: yamlFile, Args: []string{"password=-"}},
+			stdin: "from-stdin",
+			want:  map[string]interface{}{"user": "app", "password": "from-stdin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.input.Data(strings.NewReader(tt.stdin))
+			if err != nil {
+				t.Fatalf("Data() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Data() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	bad := map[string]Input{
+		"nothing":        {},
+		"stdin twice":    {File: "-", Args: []string{"password=-"}},
+		"missing file":   {File: filepath.Join(t.TempDir(), "missing.json")},
+		"unknown format": {File: "-", FileFormat: "toml"},
+		"bad pair":       {Args: []string{"novalue"}},
+	}
+	for name, input := range bad {
+		if _, err := input.Data(strings.NewReader("")); err == nil {
+			t.Errorf("%s: Data() should fail", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Package values reads secret values given on the command line, from files
+// or stdin, and marks binary content so it can be stored as a string
+package values
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dautovri/ruslan-cli/pkg/transfer"
+)
+
+// BinaryPrefix marks a base64-encoded binary value
+const BinaryPrefix = "!binary:base64:"
+
+// Input is where the data of a write comes from: a file of keys and values,
+// key=value arguments, or both
+type Input struct {
+	// File is a JSON, YAML or dotenv file; "-" reads it from stdin
+	File string
+	// FileFormat is the format of File, guessed from its extension (or JSON)
+	// if empty
+	FileFormat string
+	// Args are key=value pairs as accepted by ParseArgs
+	Args []string
+}
+
+// Data reads the input. Keys from the file come first and key=value arguments
+// override them, so a file of defaults can be adjusted on the command line.
+// Stdin can only be read once, by the file or by one key.
+func (in Input) Data(stdin io.Reader) (map[string]interface{}, error) {
+	if in.File == "" && len(in.Args) == 0 {
+		return nil, errors.New("no data: give key=value pairs or --file")
+	}
+	if in.File == "-" {
+		for _, arg := range in.Args {
+			if _, value, _ := strings.Cut(arg, "="); value == "-" {
+				return nil, fmt.Errorf("%s and --file - both read from stdin", arg)
+			}
+		}
+	}
+
+	data := make(map[string]interface{})
+	if in.File != "" {
+		fileData, err := ReadFile(in.File, in.FileFormat, stdin)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileData {
+			data[k] = v
+		}
+	}
+
+	argData, err := ParseArgs(in.Args, stdin)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range argData {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// ReadFile reads the data of one secret from a JSON, YAML or dotenv file, or
+// from stdin if file is "-". The format is guessed from the extension if empty,
+// falling back to JSON.
+func ReadFile(file, format string, stdin io.Reader) (map[string]interface{}, error) {
+	if format == "" {
+		format = transfer.FormatFromPath(file)
+	}
+	if format == "" {
+		format = transfer.FormatJSON
+	}
+
+	r := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := transfer.ReadData(r, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return data, nil
+}
+
+// FromBytes returns text as is and base64-encodes binary data behind
+// BinaryPrefix
+func FromBytes(b []byte) string {
+	if utf8.Valid(b) && !bytes.ContainsRune(b, 0) {
+		return string(b)
+	}
+	return BinaryPrefix + base64.StdEncoding.EncodeToString(b)
+}
+
+// Binary decodes a value stored by FromBytes. It reports false for anything
+// that isn't a marked binary value.
+func Binary(v interface{}) ([]byte, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, BinaryPrefix) {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(s, BinaryPrefix))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// String passes strings as they are and encodes anything else as JSON
+func String(v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseArgs parses key=value arguments. A value of @path is read from a file
+// and - from stdin (for one key only); @@ stands for a literal leading @.
+func ParseArgs(args []string, stdin io.Reader) (map[string]interface{}, error) {
+	data := make(map[string]interface{}, len(args))
+	stdinKey := ""
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid key=value pair: %s", arg)
+		}
+
+		switch {
+		case value == "-":
+			if stdinKey != "" {
+				return nil, fmt.Errorf("both %s and %s read from stdin", stdinKey, key)
+			}
+			stdinKey = key
+			b, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from stdin: %w", key, err)
+			}
+			data[key] = FromBytes(b)
+		case strings.HasPrefix(value, "@@"):
+			data[key] = value[1:]
+		case strings.HasPrefix(value, "@"):
+			b, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", key, err)
+			}
+			data[key] = FromBytes(b)
+		default:
+			data[key] = value
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,89 @@
+package values
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	blob := filepath.Join(dir, "keystore.jks")
+	if err := os.WriteFile(cert, []byte("-----BEGIN CERTIFICATE-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte{0xfe, 0xed, 0x00, 0x01}
+	if err := os.WriteFile(blob, binary, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ParseArgs([]string{
+		"user=app",
+		"empty=",
+		"cert=@" + cert,
+		"keystore=@" + blob,
+		"password=-",
+		"handle=@@app",
+		"url=postgres://a=b",
+	}, strings.NewReader("s3cret\n"))
+	if err != nil {
+		t.Fatalf("ParseArgs() error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"user":     "app",
+		"empty":    "",
+		"cert":     "-----BEGIN CERTIFICATE-----\n",
+		"keystore": BinaryPrefix + "/u0AAQ==",
+		"password": "s3cret\n",
+		"handle":   "@app",
+		"url":      "postgres://a=b",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("ParseArgs() = %v, want %v", data, want)
+	}
+
+	decoded, ok := Binary(data["keystore"])
+	if !ok || !reflect.DeepEqual(decoded, binary) {
+		t.Errorf("Binary() = %v, %v; want %v, true", decoded, ok, binary)
+	}
+	if _, ok := Binary(data["cert"]); ok {
+		t.Error("Binary() of a text value reports true")
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	bad := [][]string{
+		{"novalue"},
+		{"=value"},
+		{"a=-", "b=-"},
+		{"a=@" + filepath.Join(t.TempDir(), "missing")},
+	}
+	for _, args := range bad {
+		if _, err := ParseArgs(args, strings.NewReader("")); err == nil {
+			t.Errorf("ParseArgs(%q) should fail", args)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"plain", "plain"},
+		{json.Number("8080"), "8080"},
+		{true, "true"},
+		{map[string]interface{}{"a": []interface{}{"b"}}, `{"a":["b"]}`},
+	}
+	for _, tt := range tests {
+		got, err := String(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("String(%#v) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
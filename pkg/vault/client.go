@@ -1,33 +1,85 @@
 package vault
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"os"
+	"time"
 
 	"github.com/dautovri/ruslan-cli/pkg/auth"
 	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
 	vaultapi "github.com/hashicorp/vault/api"
 )
 
+// Client wraps the Vault API client with the environment it was created for
 type Client struct {
 	*vaultapi.Client
-	Config *config.Config
+	Config  *config.Config
+	EnvName string
+	Env     *config.Environment
+	Tokens  tokenstore.Store
+
+	entry    *tokenstore.Entry
+	mounts   *mountCache
+	recorder *Recorder
+}
+
+// NewClient creates a client for the given environment. An empty envName
+// falls back to RUSLAN_ENV and then to the saved current environment.
+func NewClient(envName string) (*Client, error) {
+	return newClient(envName, false)
+}
+
+// NewDryRunClient creates a client like NewClient that records writes,
+// deletes and logins instead of sending them (see Client.Record). The saved
+// token isn't refreshed and no configuration is saved.
+func NewDryRunClient(envName string) (*Client, error) {
+	return newClient(envName, true)
 }
 
-func NewClient() (*Client, error) {
+func newClient(envName string, dryRun bool) (*Client, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, err
 	}
 
-	env := cfg.Environments[cfg.CurrentEnvironment]
-	if env == nil {
-		return nil, fmt.Errorf("environment not found: %s", cfg.CurrentEnvironment)
+	tokens, err := tokenstore.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		// Refreshing may renew the token or log in again, and saves the result
+		cfg.AutoRefresh = false
+	} else if _, err := tokenstore.MigrateConfig(tokens); err != nil {
+		// Older versions kept tokens in config.yaml
+		return nil, fmt.Errorf("failed to migrate saved tokens: %w", err)
+	}
+
+	c, err := NewClientWithStore(cfg, tokens, envName)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		if _, err := c.Record(true); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// NewClientWithStore creates a client for an environment of cfg, with tokens
+// loaded from and saved to the given store
+func NewClientWithStore(cfg *config.Config, tokens tokenstore.Store, envName string) (*Client, error) {
+	name, env, err := cfg.ResolveEnvironment(envName)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use configured Vault address
 	if env.VaultAddr == "" {
-		return nil, fmt.Errorf("vault address not configured for environment: %s", cfg.CurrentEnvironment)
+		return nil, fmt.Errorf("vault address not configured for environment: %s", name)
 	}
 
 	vaultCfg := vaultapi.DefaultConfig()
@@ -39,58 +91,127 @@ func NewClient() (*Client, error) {
 	}
 
 	// Load saved token if exists
-	if env.Token != "" {
-		client.SetToken(env.Token)
+	entry, err := tokens.Load(env.VaultAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saved token: %w", err)
+	}
+	if entry != nil && entry.Token != "" {
+		client.SetToken(entry.Token)
+	}
+
+	c := &Client{
+		Client:  client,
+		Config:  cfg,
+		EnvName: name,
+		Env:     env,
+		Tokens:  tokens,
+		entry:   entry,
+		mounts:  newMountCache(cfg.CacheDir, env.VaultAddr),
+	}
+
+	// A failed refresh isn't fatal: the command reports auth errors itself
+	if cfg.AutoRefresh {
+		if err := c.refreshToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 	}
 
-	return &Client{
-		Client: client,
-		Config: cfg,
-	}, nil
+	return c, nil
 }
 
+// SaveToken stores a token without expiry information for the client's Vault address
 func (c *Client) SaveToken(token string) error {
-	c.Config.Environments[c.Config.CurrentEnvironment].Token = token
-	return c.Config.Save()
+	return c.saveEntry(&tokenstore.Entry{Token: token})
+}
+
+// TokenEntry returns the saved login state, or nil if not logged in
+func (c *Client) TokenEntry() *tokenstore.Entry {
+	return c.entry
+}
+
+func (c *Client) saveEntry(entry *tokenstore.Entry) error {
+	c.entry = entry
+	if c.DryRun() {
+		return nil
+	}
+	return c.Tokens.Save(c.Env.VaultAddr, entry)
 }
 
 // LoginWithToken authenticates with a token
 func (c *Client) LoginWithToken(token string) error {
-	err := auth.LoginWithToken(c.Client, token)
+	info, err := auth.LoginWithToken(c.Client, token)
 	if err != nil {
 		return err
 	}
-	return c.SaveToken(token)
+
+	entry := &tokenstore.Entry{Token: token, Method: tokenstore.MethodToken}
+	if ttl, err := info.TokenTTL(); err == nil && ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+	entry.Renewable, _ = info.TokenIsRenewable()
+	return c.saveEntry(entry)
 }
 
-// LoginWithUserPass authenticates with username/password
-func (c *Client) LoginWithUserPass(username, password string) (string, error) {
-	token, err := auth.LoginWithUserPass(c.Client, username, password)
+// LoginWithUserPass authenticates with username/password. With saveCredentials
+// the password is kept in the token store so auto refresh can log in again.
+func (c *Client) LoginWithUserPass(username, password string, saveCredentials bool) (string, error) {
+	if saveCredentials && !tokenstore.Secure(c.Tokens) {
+		return "", errInsecureCredentialStore
+	}
+
+	secretAuth, err := auth.LoginWithUserPass(c.Client, username, password)
+	if c.DryRun() {
+		// The login was recorded but not sent, so there is no token to save
+		return "", nil
+	}
 	if err != nil {
 		return "", err
 	}
-	if err := c.SaveToken(token); err != nil {
+
+	entry := entryFromAuth(tokenstore.MethodUserPass, secretAuth)
+	if saveCredentials {
+		entry.Credentials = map[string]string{"username": username, "password": password}
+	}
+	if err := c.saveEntry(entry); err != nil {
 		return "", err
 	}
-	return token, nil
+	return entry.Token, nil
 }
 
-// LoginWithAppRole authenticates with AppRole
-func (c *Client) LoginWithAppRole(roleID, secretID string) (string, error) {
-	token, err := auth.LoginWithAppRole(c.Client, roleID, secretID)
+// LoginWithAppRole authenticates with AppRole. With saveCredentials the
+// role and secret IDs are kept in the token store so auto refresh can log in again.
+func (c *Client) LoginWithAppRole(roleID, secretID string, saveCredentials bool) (string, error) {
+	if saveCredentials && !tokenstore.Secure(c.Tokens) {
+		return "", errInsecureCredentialStore
+	}
+
+	secretAuth, err := auth.LoginWithAppRole(c.Client, roleID, secretID)
+	if c.DryRun() {
+		// The login was recorded but not sent, so there is no token to save
+		return "", nil
+	}
 	if err != nil {
 		return "", err
 	}
-	if err := c.SaveToken(token); err != nil {
+
+	entry := entryFromAuth(tokenstore.MethodAppRole, secretAuth)
+	if saveCredentials {
+		entry.Credentials = map[string]string{"role_id": roleID, "secret_id": secretID}
+	}
+	if err := c.saveEntry(entry); err != nil {
 		return "", err
 	}
-	return token, nil
+	return entry.Token, nil
 }
 
-// Logout clears the saved token
+// Logout clears the saved token and credentials; a dry run keeps them saved
 func (c *Client) Logout() error {
-	c.Config.Environments[c.Config.CurrentEnvironment].Token = ""
-	return c.Config.Save()
+	c.ClearToken()
+	c.entry = nil
+	if c.DryRun() {
+		return nil
+	}
+	return c.Tokens.Delete(c.Env.VaultAddr)
 }
 
 // GetTokenInfo returns information about the current token
@@ -98,15 +219,23 @@ func (c *Client) GetTokenInfo() (*vaultapi.Secret, error) {
 	return c.Auth().Token().LookupSelf()
 }
 
-// ListSecrets lists secrets at a path (handles KV v2)
+// ErrSecretNotFound is returned when a path holds no (readable) secret
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret is the key/value data of a KV secret. Metadata and Version are only
+// set for KV v2.
+type Secret struct {
+	Path     string
+	Data     map[string]interface{}
+	Metadata map[string]interface{}
+	Version  int
+}
+
+// ListSecrets lists secrets at a path. Folders end with a slash.
 func (c *Client) ListSecrets(path string) ([]string, error) {
-	// For KV v2, we need to add /metadata to list secrets
-	metadataPath := path
-	if !strings.HasPrefix(path, "secret/metadata/") && strings.HasPrefix(path, "secret/") {
-		metadataPath = strings.Replace(path, "secret/", "secret/metadata/", 1)
-	}
+	p := c.resolvePath(path)
 
-	secret, err := c.Logical().List(metadataPath)
+	secret, err := c.Logical().List(p.metadata())
 	if err != nil {
 		return nil, err
 	}
@@ -129,40 +258,72 @@ func (c *Client) ListSecrets(path string) ([]string, error) {
 	return result, nil
 }
 
-// GetSecret reads a secret (handles KV v2)
-func (c *Client) GetSecret(path string) (*vaultapi.Secret, error) {
-	// For KV v2, we need to add /data to the path
-	dataPath := path
-	if !strings.HasPrefix(path, "secret/data/") && strings.HasPrefix(path, "secret/") {
-		dataPath = strings.Replace(path, "secret/", "secret/data/", 1)
-	}
-	return c.Logical().Read(dataPath)
+// GetSecret reads the latest version of a secret
+func (c *Client) GetSecret(path string) (*Secret, error) {
+	return c.GetSecretVersion(path, 0)
 }
 
-// PutSecret writes a secret (handles KV v2)
+// PutSecret writes a secret, replacing all of its data
 func (c *Client) PutSecret(path string, data map[string]interface{}) error {
-	// For KV v2, we need to add /data to the path and wrap data in "data" key
-	dataPath := path
-	if !strings.HasPrefix(path, "secret/data/") && strings.HasPrefix(path, "secret/") {
-		dataPath = strings.Replace(path, "secret/", "secret/data/", 1)
+	_, err := c.writeData(c.resolvePath(path), data, nil)
+	return err
+}
+
+// writeData writes data to p and returns the new KV v2 version (0 on KV v1).
+// options are KV v2 write options such as "cas".
+func (c *Client) writeData(p kvPath, data, options map[string]interface{}) (int, error) {
+	if !p.isV2() {
+		if len(options) > 0 {
+			return 0, errNotV2(p, "write options")
+		}
+		_, err := c.Logical().Write(p.data(), data)
+		return 0, err
 	}
 
-	// Wrap the data for KV v2
-	wrappedData := map[string]interface{}{
+	// KV v2 expects the key/values wrapped in "data"
+	body := map[string]interface{}{
 		"data": data,
 	}
+	if len(options) > 0 {
+		body["options"] = options
+	}
 
-	_, err := c.Logical().Write(dataPath, wrappedData)
-	return err
+	secret, err := c.Logical().Write(p.data(), body)
+	if err != nil {
+		return 0, err
+	}
+	if secret == nil {
+		return 0, nil
+	}
+	return jsonInt(secret.Data["version"]), nil
 }
 
-// DeleteSecret deletes a secret (handles KV v2)
+// DeleteSecret deletes a secret. On KV v2 this soft-deletes the latest version.
 func (c *Client) DeleteSecret(path string) error {
-	// For KV v2, we need to add /data to the path
-	dataPath := path
-	if !strings.HasPrefix(path, "secret/data/") && strings.HasPrefix(path, "secret/") {
-		dataPath = strings.Replace(path, "secret/", "secret/data/", 1)
-	}
-	_, err := c.Logical().Delete(dataPath)
+	p := c.resolvePath(path)
+
+	_, err := c.Logical().Delete(p.data())
 	return err
 }
+
+// parseSecret unwraps a read response into a Secret
+func parseSecret(p kvPath, secret *vaultapi.Secret) (*Secret, error) {
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("%w at %s", ErrSecretNotFound, p)
+	}
+	if !p.isV2() {
+		return &Secret{Path: p.String(), Data: secret.Data}, nil
+	}
+
+	result := &Secret{Path: p.String()}
+	result.Metadata, _ = secret.Data["metadata"].(map[string]interface{})
+	result.Version = jsonInt(result.Metadata["version"])
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		// KV v2 returns the metadata of deleted and destroyed versions without data
+		return nil, fmt.Errorf("%w at %s: version %d was deleted or destroyed", ErrSecretNotFound, p, result.Version)
+	}
+	result.Data = data
+	return result, nil
+}
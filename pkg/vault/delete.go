@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"fmt"
+)
+
+// KVVersion returns the KV engine version of path: 1, 2, or 0 if it isn't a
+// known KV mount
+func (c *Client) KVVersion(path string) int {
+	return c.resolvePath(path).mount.Version
+}
+
+// DeleteVersions soft-deletes specific KV v2 versions; they can be undeleted
+func (c *Client) DeleteVersions(path string, versions []int) error {
+	return c.versionsOp(path, "delete", versions)
+}
+
+// UndeleteVersions restores soft-deleted KV v2 versions
+func (c *Client) UndeleteVersions(path string, versions []int) error {
+	return c.versionsOp(path, "undelete", versions)
+}
+
+// DestroyVersions permanently removes the data of KV v2 versions
+func (c *Client) DestroyVersions(path string, versions []int) error {
+	return c.versionsOp(path, "destroy", versions)
+}
+
+// DeleteMetadata permanently deletes a KV v2 secret with all of its versions
+// and metadata
+func (c *Client) DeleteMetadata(path string) error {
+	p := c.resolvePath(path)
+	if !p.isV2() {
+		return errNotV2(p, "metadata deletes")
+	}
+
+	_, err := c.Logical().Delete(p.metadata())
+	return err
+}
+
+func (c *Client) versionsOp(path, op string, versions []int) error {
+	p := c.resolvePath(path)
+	if !p.isV2() {
+		return errNotV2(p, op+" of versions")
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions given to %s", op)
+	}
+
+	_, err := c.Logical().Write(p.api(op), map[string]interface{}{
+		"versions": versions,
+	})
+	return err
+}
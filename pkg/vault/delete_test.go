@@ -0,0 +1,48 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestDeleteUndeleteDestroy(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	srv.Put("secret/app", map[string]interface{}{"password": "one"})
+	srv.Put("secret/app", map[string]interface{}{"password": "two"})
+
+	if err := client.DeleteVersions("secret/app", []int{2}); err != nil {
+		t.Fatalf("DeleteVersions() error: %v", err)
+	}
+	if _, err := client.GetSecret("secret/app"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret() after delete error = %v, want ErrSecretNotFound", err)
+	}
+
+	if err := client.UndeleteVersions("secret/app", []int{2}); err != nil {
+		t.Fatalf("UndeleteVersions() error: %v", err)
+	}
+	if secret, err := client.GetSecret("secret/app"); err != nil || secret.Data["password"] != "two" {
+		t.Errorf("GetSecret() after undelete = %v, %v; want password=two", secret, err)
+	}
+
+	if err := client.DestroyVersions("secret/app", []int{1}); err != nil {
+		t.Fatalf("DestroyVersions() error: %v", err)
+	}
+	meta, err := client.ReadMetadata("secret/app")
+	if err != nil {
+		t.Fatalf("ReadMetadata() error: %v", err)
+	}
+	if !meta.Versions[0].Destroyed || meta.Versions[1].Destroyed {
+		t.Errorf("versions = %+v, want only version 1 destroyed", meta.Versions)
+	}
+
+	if err := client.DeleteMetadata("secret/app"); err != nil {
+		t.Fatalf("DeleteMetadata() error: %v", err)
+	}
+	if _, err := client.ReadMetadata("secret/app"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("ReadMetadata() after metadata delete error = %v, want ErrSecretNotFound", err)
+	}
+}
@@ -0,0 +1,227 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeType says how a key differs between two secrets
+type ChangeType string
+
+// Key change types
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// KeyChange is a key that differs between two secrets. Old is unset for added
+// keys and New for removed ones.
+type KeyChange struct {
+	Key  string      `json:"key" yaml:"key"`
+	Type ChangeType  `json:"type" yaml:"type"`
+	Old  interface{} `json:"old,omitempty" yaml:"old,omitempty"`
+	New  interface{} `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+// NormalizeData returns data the way Vault returns it after a write: values
+// go through JSON and numbers come back as json.Number. Data decoded from YAML
+// then compares equal to what Vault holds.
+func NormalizeData(data map[string]interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(encoded))
+	dec.UseNumber()
+	if err := dec.Decode(&normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// DiffData returns the keys that change when going from old to new, sorted by
+// key. Either side may be nil.
+func DiffData(old, new map[string]interface{}) []KeyChange {
+	var changes []KeyChange
+	for key, newValue := range new {
+		oldValue, ok := old[key]
+		switch {
+		case !ok:
+			changes = append(changes, KeyChange{Key: key, Type: ChangeAdded, New: newValue})
+		case !reflect.DeepEqual(oldValue, newValue):
+			changes = append(changes, KeyChange{Key: key, Type: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, oldValue := range old {
+		if _, ok := new[key]; !ok {
+			changes = append(changes, KeyChange{Key: key, Type: ChangeRemoved, Old: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// SecretRef points at a secret as written on the command line:
+// [env:]path[@version], e.g. "dev:secret/app@3"
+type SecretRef struct {
+	Env     string // "" for the selected environment
+	Path    string
+	Version int // 0 for the latest version
+}
+
+// ParseSecretRef parses [env:]path[@version]. An "@" not followed by a
+// version number is taken as part of the path.
+func ParseSecretRef(s string) (SecretRef, error) {
+	var ref SecretRef
+	if i := strings.Index(s, ":"); i > 0 && !strings.Contains(s[:i], "/") {
+		ref.Env, s = s[:i], s[i+1:]
+	}
+	if i := strings.LastIndex(s, "@"); i > strings.LastIndex(s, "/") {
+		if version, err := strconv.Atoi(s[i+1:]); err == nil {
+			if version <= 0 {
+				return ref, fmt.Errorf("invalid version in %q: must be positive", s)
+			}
+			ref.Version, s = version, s[:i]
+		}
+	}
+	if s == "" {
+		return ref, fmt.Errorf("missing path in secret reference")
+	}
+	ref.Path = s
+	return ref, nil
+}
+
+// String formats the reference the way ParseSecretRef reads it
+func (r SecretRef) String() string {
+	s := r.Path
+	if r.Env != "" {
+		s = r.Env + ":" + s
+	}
+	if r.Version > 0 {
+		s += "@" + strconv.Itoa(r.Version)
+	}
+	return s
+}
+
+// SecretDiff is a secret that differs between two sides. Type is ChangeAdded
+// or ChangeRemoved when the secret only exists on the new or old side.
+type SecretDiff struct {
+	Path    string      `json:"path" yaml:"path"`
+	Type    ChangeType  `json:"type" yaml:"type"`
+	Changes []KeyChange `json:"changes" yaml:"changes"`
+}
+
+// DiffSecret compares two secrets, either of which may be nil if it doesn't
+// exist. It returns nil when they hold the same data.
+func DiffSecret(path string, old, new *Secret) *SecretDiff {
+	var oldData, newData map[string]interface{}
+	if old != nil {
+		oldData = old.Data
+	}
+	if new != nil {
+		newData = new.Data
+	}
+
+	changes := DiffData(oldData, newData)
+	if len(changes) == 0 && (old == nil) == (new == nil) {
+		return nil
+	}
+
+	diff := &SecretDiff{Path: path, Type: ChangeChanged, Changes: changes}
+	switch {
+	case old == nil:
+		diff.Type = ChangeAdded
+	case new == nil:
+		diff.Type = ChangeRemoved
+	}
+	return diff
+}
+
+// TreeDiff is the result of comparing two folders. Paths are relative to the
+// compared folders.
+type TreeDiff struct {
+	Old       string       `json:"old" yaml:"old"`
+	New       string       `json:"new" yaml:"new"`
+	Secrets   []SecretDiff `json:"secrets" yaml:"secrets"`
+	Unchanged int          `json:"unchanged" yaml:"unchanged"`
+	// Errors are folders on either side that couldn't be listed
+	Errors []WalkError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// DiffTree compares every secret below oldRoot in oldClient with the secret at
+// the same relative path below newRoot in newClient. Both may be the same client.
+func DiffTree(ctx context.Context, oldClient *Client, oldRoot string, newClient *Client, newRoot string, opts WalkOptions) (*TreeDiff, error) {
+	oldTree, err := oldClient.Walk(ctx, oldRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+	newTree, err := newClient.Walk(ctx, newRoot, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TreeDiff{
+		Old: oldTree.Root,
+		New: newTree.Root,
+	}
+	result.Errors = append(result.Errors, oldTree.Errors...)
+	result.Errors = append(result.Errors, newTree.Errors...)
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, tree := range []*WalkResult{oldTree, newTree} {
+		for _, p := range tree.Secrets() {
+			rel := strings.TrimPrefix(p, tree.Root)
+			if !seen[rel] {
+				seen[rel] = true
+				paths = append(paths, rel)
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	for _, rel := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		oldSecret, err := readOptional(oldClient, oldTree.Root+rel)
+		if err != nil {
+			return nil, err
+		}
+		newSecret, err := readOptional(newClient, newTree.Root+rel)
+		if err != nil {
+			return nil, err
+		}
+
+		if diff := DiffSecret(rel, oldSecret, newSecret); diff != nil {
+			result.Secrets = append(result.Secrets, *diff)
+		} else {
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// readOptional reads the latest version of path, returning nil if it doesn't exist
+func readOptional(c *Client, path string) (*Secret, error) {
+	secret, err := c.GetSecret(path)
+	if errors.Is(err, ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in %s: %w", path, c.EnvName, err)
+	}
+	return secret, nil
+}
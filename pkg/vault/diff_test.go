@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestDiffData(t *testing.T) {
+	old := map[string]interface{}{"same": "1", "changed": "a", "removed": "x"}
+	new := map[string]interface{}{"same": "1", "changed": "b", "added": "y"}
+
+	want := []KeyChange{
+		{Key: "added", Type: ChangeAdded, New: "y"},
+		{Key: "changed", Type: ChangeChanged, Old: "a", New: "b"},
+		{Key: "removed", Type: ChangeRemoved, Old: "x"},
+	}
+	if got := DiffData(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffData() = %+v, want %+v", got, want)
+	}
+
+	if got := DiffData(nil, nil); len(got) != 0 {
+		t.Errorf("DiffData(nil, nil) = %+v, want no changes", got)
+	}
+}
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want SecretRef
+	}{
+		{"secret/app", SecretRef{Path: "secret/app"}},
+		{"dev:secret/app@3", SecretRef{Env: "dev", Path: "secret/app", Version: 3}},
+		{"prod:secret/app", SecretRef{Env: "prod", Path: "secret/app"}},
+		{"secret/user@example.com", SecretRef{Path: "secret/user@example.com"}},
+		{"secret/a:b/c", SecretRef{Path: "secret/a:b/c"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseSecretRef(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSecretRef(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("String() = %q, want %q", got.String(), tt.in)
+		}
+	}
+
+	for _, bad := range []string{"dev:", "secret/app@0"} {
+		if _, err := ParseSecretRef(bad); err == nil {
+			t.Errorf("ParseSecretRef(%q) should fail", bad)
+		}
+	}
+}
+
+func TestDiffTree(t *testing.T) {
+	devSrv := vaulttest.NewServer(t)
+	prodSrv := vaulttest.NewServer(t)
+	dev := newTestClient(t, devSrv, t.TempDir())
+	prod := newTestClient(t, prodSrv, t.TempDir())
+
+	devSrv.Put("secret/app/same", map[string]interface{}{"a": "1"})
+	devSrv.Put("secret/app/db", map[string]interface{}{"user": "dev"})
+	devSrv.Put("secret/app/dev-only", map[string]interface{}{"x": "1"})
+	prodSrv.Put("secret/prod-app/same", map[string]interface{}{"a": "1"})
+	prodSrv.Put("secret/prod-app/db", map[string]interface{}{"user": "prod"})
+	prodSrv.Put("secret/prod-app/nested/prod-only", map[string]interface{}{"y": "2"})
+
+	diff, err := DiffTree(context.Background(), dev, "secret/app", prod, "secret/prod-app/", WalkOptions{})
+	if err != nil {
+		t.Fatalf("DiffTree() error: %v", err)
+	}
+
+	want := []SecretDiff{
+		{Path: "db", Type: ChangeChanged, Changes: []KeyChange{{Key: "user", Type: ChangeChanged, Old: "dev", New: "prod"}}},
+		{Path: "dev-only", Type: ChangeRemoved, Changes: []KeyChange{{Key: "x", Type: ChangeRemoved, Old: "1"}}},
+		{Path: "nested/prod-only", Type: ChangeAdded, Changes: []KeyChange{{Key: "y", Type: ChangeAdded, New: "2"}}},
+	}
+	if !reflect.DeepEqual(diff.Secrets, want) {
+		t.Errorf("Secrets = %+v, want %+v", diff.Secrets, want)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", diff.Unchanged)
+	}
+
+	if d := DiffSecret("same", &Secret{Data: map[string]interface{}{"a": "1"}}, &Secret{Data: map[string]interface{}{"a": "1"}}); d != nil {
+		t.Errorf("DiffSecret(equal) = %+v, want nil", d)
+	}
+}
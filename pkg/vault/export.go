@@ -0,0 +1,122 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExportOptions controls what an export covers besides the current data
+type ExportOptions struct {
+	Recursive bool
+	// Metadata adds the KV v2 metadata and version history of each secret
+	Metadata bool
+	// Versions adds the data of every older version that wasn't deleted or destroyed
+	Versions bool
+	Walk     WalkOptions
+}
+
+// ExportedSecret is one secret in an export. Version, Metadata and Versions
+// are only set on KV v2 when requested.
+type ExportedSecret struct {
+	Data     map[string]interface{}         `json:"data" yaml:"data"`
+	Version  int                            `json:"version,omitempty" yaml:"version,omitempty"`
+	Metadata *SecretMetadata                `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Versions map[int]map[string]interface{} `json:"versions,omitempty" yaml:"versions,omitempty"`
+}
+
+// Export is a prepared export of one secret or a folder. Only the paths are
+// kept in memory; Each reads the secrets one at a time, so large trees can be
+// streamed to a file.
+type Export struct {
+	// Root is the folder the exported paths are relative to
+	Root string
+	// Paths are the secrets to export, in order
+	Paths []string
+	// Errors are folders that couldn't be listed; they don't stop the export
+	Errors []WalkError
+
+	client *Client
+	opts   ExportOptions
+}
+
+// PrepareExport lists the secret at path, or with opts.Recursive every secret
+// below it
+func (c *Client) PrepareExport(ctx context.Context, path string, opts ExportOptions) (*Export, error) {
+	export := &Export{client: c, opts: opts}
+
+	if opts.Recursive {
+		result, err := c.Walk(ctx, path, opts.Walk)
+		if err != nil {
+			return nil, err
+		}
+		export.Root = result.Root
+		export.Errors = result.Errors
+		for _, p := range result.Secrets() {
+			export.Paths = append(export.Paths, strings.TrimPrefix(p, result.Root))
+		}
+		return export, nil
+	}
+
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "/"), "/")
+	slash := strings.LastIndex(path, "/") + 1
+	export.Root = path[:slash]
+	export.Paths = []string{path[slash:]}
+	return export, nil
+}
+
+// Each reads the secrets in order and passes them to fn with their relative
+// path, stopping at the first error
+func (e *Export) Each(ctx context.Context, fn func(rel string, secret *ExportedSecret) error) error {
+	for _, rel := range e.Paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		secret, err := e.client.exportSecret(e.Root+rel, e.opts)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", e.Root+rel, err)
+		}
+		if err := fn(rel, secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) exportSecret(path string, opts ExportOptions) (*ExportedSecret, error) {
+	secret, err := c.GetSecret(path)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := &ExportedSecret{Data: secret.Data}
+	if (!opts.Metadata && !opts.Versions) || !c.resolvePath(path).isV2() {
+		return exported, nil
+	}
+
+	meta, err := c.ReadMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Metadata {
+		exported.Version = secret.Version
+		exported.Metadata = meta
+	}
+	if opts.Versions {
+		for _, v := range meta.Versions {
+			if v.Version == secret.Version || v.Deleted() || v.Destroyed {
+				continue
+			}
+			old, err := c.GetSecretVersion(path, v.Version)
+			if err != nil {
+				return nil, err
+			}
+			if exported.Versions == nil {
+				exported.Versions = make(map[int]map[string]interface{})
+			}
+			exported.Versions[v.Version] = old.Data
+		}
+	}
+	return exported, nil
+}
@@ -0,0 +1,55 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestExport(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	srv.Put("secret/app/api", map[string]interface{}{"token": "v1"})
+	srv.Put("secret/app/api", map[string]interface{}{"token": "v2"})
+	srv.Put("secret/app/db/creds", map[string]interface{}{"user": "app"})
+
+	export, err := client.PrepareExport(context.Background(), "secret/app", ExportOptions{
+		Recursive: true,
+		Metadata:  true,
+		Versions:  true,
+	})
+	if err != nil {
+		t.Fatalf("PrepareExport() error: %v", err)
+	}
+	if export.Root != "secret/app/" || !reflect.DeepEqual(export.Paths, []string{"api", "db/creds"}) {
+		t.Fatalf("export = %s %v, want secret/app/ [api db/creds]", export.Root, export.Paths)
+	}
+
+	got := make(map[string]*ExportedSecret)
+	err = export.Each(context.Background(), func(rel string, secret *ExportedSecret) error {
+		got[rel] = secret
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Each() error: %v", err)
+	}
+
+	api := got["api"]
+	if api.Data["token"] != "v2" || api.Version != 2 || api.Metadata == nil || api.Metadata.CurrentVersion != 2 {
+		t.Errorf("api = %+v, want version 2 with metadata", api)
+	}
+	if want := map[int]map[string]interface{}{1: {"token": "v1"}}; !reflect.DeepEqual(api.Versions, want) {
+		t.Errorf("api versions = %v, want %v", api.Versions, want)
+	}
+
+	single, err := client.PrepareExport(context.Background(), "secret/app/db/creds", ExportOptions{})
+	if err != nil {
+		t.Fatalf("PrepareExport(single) error: %v", err)
+	}
+	if single.Root != "secret/app/db/" || !reflect.DeepEqual(single.Paths, []string{"creds"}) {
+		t.Errorf("single export = %s %v, want secret/app/db/ [creds]", single.Root, single.Paths)
+	}
+}
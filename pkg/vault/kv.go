@@ -0,0 +1,215 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mountCacheTTL is how long discovered mounts are trusted before asking Vault again
+const mountCacheTTL = time.Hour
+
+// kvMount describes the secrets engine mounted at Path
+type kvMount struct {
+	Path    string `json:"path"` // with trailing slash, e.g. "secret/"
+	Type    string `json:"type"`
+	Version int    `json:"version"` // 1 or 2 for KV, 0 for other engines
+}
+
+// kvPath is a user-supplied path split into its mount and the path inside it
+type kvPath struct {
+	mount *kvMount
+	rel   string
+}
+
+// isV2 reports whether the path lives in a KV v2 engine
+func (p kvPath) isV2() bool {
+	return p.mount.Version == 2
+}
+
+// String returns the path as the user would write it
+func (p kvPath) String() string {
+	return p.mount.Path + p.rel
+}
+
+// api returns the API path for a KV v2 sub-tree ("data", "metadata", "delete",
+// "undelete", "destroy"); KV v1 and other engines use the plain path
+func (p kvPath) api(kind string) string {
+	if !p.isV2() {
+		return p.mount.Path + p.rel
+	}
+	return p.mount.Path + kind + "/" + p.rel
+}
+
+func (p kvPath) data() string     { return p.api("data") }
+func (p kvPath) metadata() string { return p.api("metadata") }
+
+// mountCache remembers discovered mounts in memory and in Config.CacheDir
+type mountCache struct {
+	mu     sync.Mutex
+	file   string
+	mounts []*kvMount
+	loaded bool
+	// readOnly keeps the cache file as it is, for dry runs
+	readOnly bool
+}
+
+type mountCacheFile struct {
+	Fetched time.Time  `json:"fetched"`
+	Mounts  []*kvMount `json:"mounts"`
+}
+
+func newMountCache(cacheDir, addr string) *mountCache {
+	c := &mountCache{}
+	if cacheDir != "" {
+		sum := sha256.Sum256([]byte(strings.TrimRight(addr, "/")))
+		c.file = filepath.Join(cacheDir, "mounts-"+hex.EncodeToString(sum[:8])+".json")
+	}
+	return c
+}
+
+// lookup returns the longest cached mount that contains path
+func (m *mountCache) lookup(path string) *kvMount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loaded {
+		m.load()
+	}
+
+	var best *kvMount
+	for _, mount := range m.mounts {
+		if strings.HasPrefix(path, mount.Path) || path+"/" == mount.Path {
+			if best == nil || len(mount.Path) > len(best.Path) {
+				best = mount
+			}
+		}
+	}
+	return best
+}
+
+// add records a newly discovered mount and rewrites the cache file
+func (m *mountCache) add(mount *kvMount) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.mounts {
+		if existing.Path == mount.Path {
+			return
+		}
+	}
+	m.mounts = append(m.mounts, mount)
+	sort.Slice(m.mounts, func(i, j int) bool { return m.mounts[i].Path < m.mounts[j].Path })
+	m.save()
+}
+
+// load reads the cache file, ignoring it when missing, unreadable or stale
+func (m *mountCache) load() {
+	m.loaded = true
+	if m.file == "" {
+		return
+	}
+
+	data, err := os.ReadFile(m.file)
+	if err != nil {
+		return
+	}
+	var cached mountCacheFile
+	if err := json.Unmarshal(data, &cached); err != nil || time.Since(cached.Fetched) > mountCacheTTL {
+		return
+	}
+	m.mounts = cached.Mounts
+}
+
+// save writes the cache file; failures only cost an extra lookup next time
+func (m *mountCache) save() {
+	if m.file == "" || m.readOnly {
+		return
+	}
+
+	data, err := json.Marshal(mountCacheFile{Fetched: time.Now(), Mounts: m.mounts})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(m.file), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(m.file, data, 0600)
+}
+
+// resolvePath finds the mount for path, asking Vault's
+// sys/internal/ui/mounts endpoint when it isn't cached
+func (c *Client) resolvePath(path string) kvPath {
+	path = strings.TrimPrefix(path, "/")
+
+	mount := c.mounts.lookup(path)
+	if mount == nil {
+		mount = c.discoverMount(path)
+	}
+
+	rel := strings.TrimPrefix(path, mount.Path)
+	if path+"/" == mount.Path {
+		rel = ""
+	}
+
+	// Accept paths that already contain the KV v2 API segment, e.g. secret/data/app
+	if mount.Version == 2 {
+		for _, kind := range []string{"data", "metadata"} {
+			if rel == kind {
+				rel = ""
+				break
+			}
+			if strings.HasPrefix(rel, kind+"/") {
+				rel = strings.TrimPrefix(rel, kind+"/")
+				break
+			}
+		}
+	}
+
+	return kvPath{mount: mount, rel: rel}
+}
+
+func (c *Client) discoverMount(path string) *kvMount {
+	secret, err := c.Logical().Read("sys/internal/ui/mounts/" + path)
+	if err != nil || secret == nil || secret.Data == nil {
+		// Older Vaults or restricted tokens can't use the endpoint, so fall back to
+		// the conventional layout: KV v2 at secret/, anything else passed through
+		return legacyMount(path)
+	}
+
+	mountPath, _ := secret.Data["path"].(string)
+	if mountPath == "" {
+		return legacyMount(path)
+	}
+
+	mount := &kvMount{Path: mountPath}
+	mount.Type, _ = secret.Data["type"].(string)
+	if mount.Type == "kv" || mount.Type == "generic" {
+		mount.Version = 1
+		if options, ok := secret.Data["options"].(map[string]interface{}); ok {
+			if v, ok := options["version"].(string); ok {
+				if n, err := strconv.Atoi(v); err == nil && n > 0 {
+					mount.Version = n
+				}
+			}
+		}
+	}
+
+	c.mounts.add(mount)
+	return mount
+}
+
+// legacyMount mirrors the old behavior of only treating secret/ as KV v2
+func legacyMount(path string) *kvMount {
+	if strings.HasPrefix(path, "secret/") || path == "secret" {
+		return &kvMount{Path: "secret/", Type: "kv", Version: 2}
+	}
+	return &kvMount{Path: "", Type: "unknown"}
+}
@@ -0,0 +1,125 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// newTestClient returns a client for srv with its own mount cache directory
+func newTestClient(t *testing.T, srv *vaulttest.Server, cacheDir string) *Client {
+	t.Helper()
+
+	vaultCfg := vaultapi.DefaultConfig()
+	vaultCfg.Address = srv.URL
+	api, err := vaultapi.NewClient(vaultCfg)
+	if err != nil {
+		t.Fatalf("failed to create API client: %v", err)
+	}
+	api.SetToken("s.test")
+
+	cfg := config.DefaultConfig()
+	cfg.CacheDir = cacheDir
+	return &Client{
+		Client:  api,
+		Config:  cfg,
+		EnvName: "dev",
+		Env:     &config.Environment{VaultAddr: srv.URL},
+		Tokens:  tokenstore.NewFileStore(t.TempDir()),
+		mounts:  newMountCache(cacheDir, srv.URL),
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Mount("apps/team", 2)
+	srv.Mount("legacy", 1)
+	client := newTestClient(t, srv, t.TempDir())
+
+	tests := []struct {
+		path         string
+		wantData     string
+		wantMetadata string
+	}{
+		{path: "secret/app/db", wantData: "secret/data/app/db", wantMetadata: "secret/metadata/app/db"},
+		{path: "secret/data/app/db", wantData: "secret/data/app/db", wantMetadata: "secret/metadata/app/db"},
+		{path: "apps/team/api", wantData: "apps/team/data/api", wantMetadata: "apps/team/metadata/api"},
+		{path: "legacy/app", wantData: "legacy/app", wantMetadata: "legacy/app"},
+		{path: "secret/", wantData: "secret/data/", wantMetadata: "secret/metadata/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p := client.resolvePath(tt.path)
+			if got := p.data(); got != tt.wantData {
+				t.Errorf("data() = %s, want %s", got, tt.wantData)
+			}
+			if got := p.metadata(); got != tt.wantMetadata {
+				t.Errorf("metadata() = %s, want %s", got, tt.wantMetadata)
+			}
+		})
+	}
+}
+
+func TestMountCache(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Mount("kv", 2)
+	cacheDir := t.TempDir()
+
+	client := newTestClient(t, srv, cacheDir)
+	client.resolvePath("kv/a")
+	client.resolvePath("kv/b/c")
+	if srv.MountLookups() != 1 {
+		t.Errorf("MountLookups = %d after two paths on one mount, want 1", srv.MountLookups())
+	}
+
+	// A new client reads the cache file instead of asking again
+	newTestClient(t, srv, cacheDir).resolvePath("kv/d")
+	if srv.MountLookups() != 1 {
+		t.Errorf("MountLookups = %d with a warm cache file, want 1", srv.MountLookups())
+	}
+}
+
+func TestSecretsOnV1AndV2Mounts(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Mount("kv", 2)
+	srv.Mount("legacy", 1)
+	client := newTestClient(t, srv, t.TempDir())
+
+	for _, path := range []string{"kv/app/db", "legacy/app/db"} {
+		t.Run(path, func(t *testing.T) {
+			data := map[string]interface{}{"user": "app", "password": "s3cret"}
+			if err := client.PutSecret(path, data); err != nil {
+				t.Fatalf("PutSecret() error: %v", err)
+			}
+
+			secret, err := client.GetSecret(path)
+			if err != nil {
+				t.Fatalf("GetSecret() error: %v", err)
+			}
+			if !reflect.DeepEqual(secret.Data, data) {
+				t.Errorf("GetSecret() data = %v, want %v", secret.Data, data)
+			}
+
+			keys, err := client.ListSecrets(path[:len(path)-len("db")])
+			if err != nil {
+				t.Fatalf("ListSecrets() error: %v", err)
+			}
+			if !reflect.DeepEqual(keys, []string{"db"}) {
+				t.Errorf("ListSecrets() = %v, want [db]", keys)
+			}
+
+			if err := client.DeleteSecret(path); err != nil {
+				t.Fatalf("DeleteSecret() error: %v", err)
+			}
+			if _, err := client.GetSecret(path); !errors.Is(err, ErrSecretNotFound) {
+				t.Errorf("GetSecret() after delete error = %v, want ErrSecretNotFound", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// maxCASRetries bounds read-modify-write attempts when others keep writing
+const maxCASRetries = 5
+
+// ErrCASMismatch is returned when a check-and-set write lost a race
+var ErrCASMismatch = errors.New("secret was modified concurrently (check-and-set version did not match)")
+
+// PutSecretCAS writes a KV v2 secret only if its current version is cas
+// (0 means the secret must not exist yet) and returns the new version
+func (c *Client) PutSecretCAS(path string, data map[string]interface{}, cas int) (int, error) {
+	version, err := c.writeData(c.resolvePath(path), data, map[string]interface{}{"cas": cas})
+	return version, casError(err)
+}
+
+// PatchSecret sets and removes individual keys, leaving the rest of the secret
+// as is, and returns the new version (0 on KV v1). A missing secret is created.
+//
+// On KV v2 the PATCH method is used; Vaults or policies without it fall back to
+// read-modify-write with check-and-set, retried when someone else wins a race.
+func (c *Client) PatchSecret(path string, set map[string]interface{}, unset []string) (int, error) {
+	p := c.resolvePath(path)
+
+	if p.isV2() {
+		// In a JSON merge patch null removes a key
+		patch := make(map[string]interface{}, len(set)+len(unset))
+		for k, v := range set {
+			patch[k] = v
+		}
+		for _, k := range unset {
+			patch[k] = nil
+		}
+
+		secret, err := c.Logical().JSONMergePatch(context.Background(), p.data(), map[string]interface{}{
+			"data": patch,
+		})
+		if err == nil {
+			if secret == nil {
+				return 0, nil
+			}
+			return jsonInt(secret.Data["version"]), nil
+		}
+		if !patchUnsupported(err) {
+			return 0, err
+		}
+	}
+
+	return c.updateSecret(p, func(data map[string]interface{}) error {
+		for k, v := range set {
+			data[k] = v
+		}
+		for _, k := range unset {
+			delete(data, k)
+		}
+		return nil
+	})
+}
+
+// UpdateSecret reads the secret at path, lets update change a copy of its data
+// (empty if the secret doesn't exist) and writes the result back, returning
+// the new version (0 on KV v1). On KV v2 the write uses check-and-set and
+// update is called again when someone else wrote the secret in between.
+func (c *Client) UpdateSecret(path string, update func(data map[string]interface{}) error) (int, error) {
+	return c.updateSecret(c.resolvePath(path), update)
+}
+
+func (c *Client) updateSecret(p kvPath, update func(data map[string]interface{}) error) (int, error) {
+	for attempt := 0; ; attempt++ {
+		version, err := c.readModifyWrite(p, update)
+		if !errors.Is(err, ErrCASMismatch) || attempt == maxCASRetries-1 {
+			return version, err
+		}
+	}
+}
+
+// readModifyWrite applies update to the current data and writes it back,
+// using check-and-set on KV v2
+func (c *Client) readModifyWrite(p kvPath, update func(data map[string]interface{}) error) (int, error) {
+	data, current, err := c.readCurrent(p)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if err := update(data); err != nil {
+		return 0, err
+	}
+
+	var options map[string]interface{}
+	if p.isV2() {
+		options = map[string]interface{}{"cas": current}
+	}
+	version, err := c.writeData(p, data, options)
+	return version, casError(err)
+}
+
+// readCurrent returns the data of p and its current KV v2 version for a
+// check-and-set write. A missing secret has nil data; on KV v2 its version is
+// still taken from the metadata, as deleted secrets keep their version counter.
+func (c *Client) readCurrent(p kvPath) (map[string]interface{}, int, error) {
+	secret, err := c.GetSecret(p.String())
+	switch {
+	case err == nil:
+		data := make(map[string]interface{}, len(secret.Data))
+		for k, v := range secret.Data {
+			data[k] = v
+		}
+		return data, secret.Version, nil
+	case errors.Is(err, ErrSecretNotFound):
+		current := 0
+		if p.isV2() {
+			if meta, err := c.ReadMetadata(p.String()); err == nil {
+				current = meta.CurrentVersion
+			}
+		}
+		return nil, current, nil
+	default:
+		return nil, 0, err
+	}
+}
+
+// patchUnsupported reports whether a PATCH failed because the Vault version or
+// the token's policy doesn't allow it, or because the secret doesn't exist yet
+func patchUnsupported(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) {
+		return false
+	}
+	switch respErr.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// casError maps Vault's check-and-set failure to ErrCASMismatch
+func casError(err error) error {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusBadRequest {
+		for _, msg := range respErr.Errors {
+			if strings.Contains(msg, "check-and-set") {
+				return ErrCASMismatch
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package vault
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestPutSecretCAS(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	version, err := client.PutSecretCAS("secret/app", map[string]interface{}{"a": "1"}, 0)
+	if err != nil || version != 1 {
+		t.Fatalf("PutSecretCAS(cas=0) = %d, %v; want 1, nil", version, err)
+	}
+
+	_, err = client.PutSecretCAS("secret/app", map[string]interface{}{"a": "2"}, 0)
+	if !errors.Is(err, ErrCASMismatch) {
+		t.Errorf("PutSecretCAS(stale cas) error = %v, want ErrCASMismatch", err)
+	}
+
+	if version, err := client.PutSecretCAS("secret/app", map[string]interface{}{"a": "2"}, 1); err != nil || version != 2 {
+		t.Errorf("PutSecretCAS(cas=1) = %d, %v; want 2, nil", version, err)
+	}
+}
+
+func TestPatchSecret(t *testing.T) {
+	for _, native := range []bool{true, false} {
+		name := "read-modify-write"
+		if native {
+			name = "PATCH"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			srv := vaulttest.NewServer(t)
+			if !native {
+				srv.DisablePatch()
+			}
+			client := newTestClient(t, srv, t.TempDir())
+			srv.Put("secret/app", map[string]interface{}{"user": "app", "password": "old", "debug": "true"})
+
+			version, err := client.PatchSecret("secret/app", map[string]interface{}{"password": "new"}, []string{"debug"})
+			if err != nil {
+				t.Fatalf("PatchSecret() error: %v", err)
+			}
+			if version != 2 {
+				t.Errorf("PatchSecret() version = %d, want 2", version)
+			}
+
+			want := map[string]interface{}{"user": "app", "password": "new"}
+			if got := srv.Data("secret/app"); !reflect.DeepEqual(got, want) {
+				t.Errorf("data after patch = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPatchSecretCreatesMissing(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	if _, err := client.PatchSecret("secret/new", map[string]interface{}{"key": "value"}, nil); err != nil {
+		t.Fatalf("PatchSecret() error: %v", err)
+	}
+	if got := srv.Data("secret/new"); got["key"] != "value" {
+		t.Errorf("data = %v, want key=value", got)
+	}
+}
+
+func TestUpdateSecretRetriesOnRace(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	srv.Put("secret/app", map[string]interface{}{"user": "app"})
+
+	calls := 0
+	version, err := client.UpdateSecret("secret/app", func(data map[string]interface{}) error {
+		calls++
+		if calls == 1 {
+			// Someone else writes between our read and write
+			srv.Put("secret/app", map[string]interface{}{"user": "app", "other": "x"})
+		}
+		if _, ok := data["token"]; !ok {
+			data["token"] = "t"
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateSecret() error: %v", err)
+	}
+	if calls != 2 || version != 3 {
+		t.Errorf("UpdateSecret() calls = %d, version = %d; want 2, 3", calls, version)
+	}
+
+	want := map[string]interface{}{"user": "app", "other": "x", "token": "t"}
+	if got := srv.Data("secret/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("data = %v, want %v", got, want)
+	}
+
+	wantErr := errors.New("stop")
+	if _, err := client.UpdateSecret("secret/app", func(map[string]interface{}) error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("UpdateSecret() error = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,164 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PlannedWrite is a secret write compared against what the target holds, so
+// it can be shown before it's applied
+type PlannedWrite struct {
+	Path    string      `json:"path" yaml:"path"`
+	Action  string      `json:"action" yaml:"action"`   // "create", "update" or "unchanged"
+	Create  bool        `json:"create" yaml:"create"`   // the secret doesn't exist yet
+	Changes []KeyChange `json:"changes" yaml:"changes"` // keys only, without values
+
+	data          map[string]interface{}
+	targetVersion int
+}
+
+// Unchanged reports whether the target already holds the data
+func (w *PlannedWrite) Unchanged() bool {
+	return !w.Create && len(w.Changes) == 0
+}
+
+// Write actions
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+)
+
+// PlanWrite compares data with the secret currently at path
+func (c *Client) PlanWrite(path string, data map[string]interface{}) (*PlannedWrite, error) {
+	return c.PlanUpdate(path, func(map[string]interface{}) (map[string]interface{}, error) {
+		return data, nil
+	})
+}
+
+// PlanUpdate reads the secret at path once and plans writing what desired
+// returns for its current data (nil if it doesn't exist). The write is checked
+// against the version of that same read, so a change made after it isn't lost.
+func (c *Client) PlanUpdate(path string, desired func(current map[string]interface{}) (map[string]interface{}, error)) (*PlannedWrite, error) {
+	current, version, err := c.readCurrent(c.resolvePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in %s: %w", path, c.EnvName, err)
+	}
+
+	// desired gets its own copy, so changing it doesn't hide changes from the diff
+	var copied map[string]interface{}
+	if current != nil {
+		copied = make(map[string]interface{}, len(current))
+		for k, v := range current {
+			copied[k] = v
+		}
+	}
+	data, err := desired(copied)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Only the keys are reported, so a plan can be shown without revealing values
+	changes := DiffData(current, data)
+	for i := range changes {
+		changes[i].Old, changes[i].New = nil, nil
+	}
+
+	w := &PlannedWrite{
+		Path:          path,
+		Create:        current == nil,
+		Changes:       changes,
+		data:          data,
+		targetVersion: version,
+	}
+	switch {
+	case w.Create:
+		w.Action = ActionCreate
+	case w.Unchanged():
+		w.Action = ActionUnchanged
+	default:
+		w.Action = ActionUpdate
+	}
+	return w, nil
+}
+
+// PlanWrites plans a write for each path in secrets with at most concurrency
+// reads in flight, and returns them sorted by path
+func (c *Client) PlanWrites(ctx context.Context, secrets map[string]map[string]interface{}, concurrency int) ([]*PlannedWrite, error) {
+	paths := make([]string, 0, len(secrets))
+	for path := range secrets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	writes := make([]*PlannedWrite, len(paths))
+	errs := forEach(ctx, len(paths), concurrency, func(i int) error {
+		w, err := c.PlanWrite(paths[i], secrets[paths[i]])
+		writes[i] = w
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return writes, nil
+}
+
+// ApplyWrite writes the planned data and returns the new version (0 on KV
+// v1). On KV v2 the write uses check-and-set against the version that was
+// compared, so a secret changed since the plan isn't overwritten.
+func (c *Client) ApplyWrite(w *PlannedWrite) (int, error) {
+	p := c.resolvePath(w.Path)
+
+	var options map[string]interface{}
+	if p.isV2() {
+		options = map[string]interface{}{"cas": w.targetVersion}
+	}
+	version, err := c.writeData(p, w.data, options)
+	return version, casError(err)
+}
+
+// ApplyWrites applies writes with at most concurrency in flight and returns
+// the error of each write by index, nil for those that succeeded
+func (c *Client) ApplyWrites(ctx context.Context, writes []*PlannedWrite, concurrency int) []error {
+	return forEach(ctx, len(writes), concurrency, func(i int) error {
+		_, err := c.ApplyWrite(writes[i])
+		return err
+	})
+}
+
+// forEach runs fn for 0..n-1 with at most concurrency calls in flight and
+// returns their errors by index. Calls not started before ctx is done get
+// ctx's error.
+func forEach(ctx context.Context, n, concurrency int, fn func(i int) error) []error {
+	if concurrency <= 0 {
+		concurrency = DefaultWalkConcurrency
+	}
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < n; j++ {
+				errs[j] = ctx.Err()
+			}
+			wg.Wait()
+			return errs
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	return errs
+}
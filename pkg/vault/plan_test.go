@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestPlanAndApplyWrites(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	srv.Put("secret/app/same", map[string]interface{}{"a": "1"})
+	srv.Put("secret/app/changed", map[string]interface{}{"a": "1"})
+	srv.Put("secret/app/raced", map[string]interface{}{"a": "1"})
+
+	writes, err := client.PlanWrites(context.Background(), map[string]map[string]interface{}{
+		"secret/app/new":     {"a": "1"},
+		"secret/app/same":    {"a": "1"},
+		"secret/app/changed": {"a": "2"},
+		"secret/app/raced":   {"a": "2"},
+	}, 2)
+	if err != nil {
+		t.Fatalf("PlanWrites() error: %v", err)
+	}
+
+	var actions []string
+	for _, w := range writes {
+		actions = append(actions, w.Path+"="+w.Action)
+	}
+	want := []string{
+		"secret/app/changed=update",
+		"secret/app/new=create",
+		"secret/app/raced=update",
+		"secret/app/same=unchanged",
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+
+	srv.Put("secret/app/raced", map[string]interface{}{"a": "someone else"})
+
+	errs := client.ApplyWrites(context.Background(), writes[:3], 2)
+	if errs[0] != nil || errs[1] != nil {
+		t.Errorf("ApplyWrites() errors = %v, want the first two to succeed", errs)
+	}
+	if !errors.Is(errs[2], ErrCASMismatch) {
+		t.Errorf("raced write error = %v, want ErrCASMismatch", errs[2])
+	}
+	if got := srv.Data("secret/app/raced")["a"]; got != "someone else" {
+		t.Errorf("raced secret was overwritten: a = %v", got)
+	}
+	if got := srv.Data("secret/app/new")["a"]; got != "1" {
+		t.Errorf("new secret a = %v, want 1", got)
+	}
+}
+
+func TestPlanUpdateUsesOneRead(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	srv.Put("secret/app", map[string]interface{}{"a": "1", "b": "1"})
+
+	w, err := client.PlanUpdate("secret/app", func(current map[string]interface{}) (map[string]interface{}, error) {
+		// Someone writes between the read and the plan
+		srv.Put("secret/app", map[string]interface{}{"a": "1", "b": "1", "c": "new"})
+
+		current["b"] = "2"
+		return current, nil
+	})
+	if err != nil {
+		t.Fatalf("PlanUpdate() error: %v", err)
+	}
+	if w.Action != ActionUpdate || len(w.Changes) != 1 || w.Changes[0].Key != "b" {
+		t.Fatalf("PlanUpdate() = %s %+v, want an update of b", w.Action, w.Changes)
+	}
+
+	if _, err := client.ApplyWrite(w); !errors.Is(err, ErrCASMismatch) {
+		t.Errorf("ApplyWrite() error = %v, want ErrCASMismatch", err)
+	}
+	if got := srv.Data("secret/app")["c"]; got != "new" {
+		t.Errorf("concurrent write was lost: c = %v", got)
+	}
+}
@@ -0,0 +1,62 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+)
+
+// PromotionPlan is the result of comparing a path between two environments
+type PromotionPlan struct {
+	From       string          `json:"from" yaml:"from"`
+	To         string          `json:"to" yaml:"to"`
+	Promotions []*PlannedWrite `json:"promotions" yaml:"promotions"`
+	// Errors are source folders that couldn't be listed in a recursive plan
+	Errors []WalkError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// Pending returns the promotions that would change the target
+func (p *PromotionPlan) Pending() []*PlannedWrite {
+	var pending []*PlannedWrite
+	for _, promotion := range p.Promotions {
+		if !promotion.Unchanged() {
+			pending = append(pending, promotion)
+		}
+	}
+	return pending
+}
+
+// PlanPromotion compares path in src with the same path in dst. With
+// recursive, path is a folder and every secret below it is compared.
+func PlanPromotion(ctx context.Context, src, dst *Client, path string, recursive bool, opts WalkOptions) (*PromotionPlan, error) {
+	plan := &PromotionPlan{From: src.EnvName, To: dst.EnvName}
+
+	paths := []string{path}
+	if recursive {
+		result, err := src.Walk(ctx, path, opts)
+		if err != nil {
+			return nil, err
+		}
+		paths = result.Secrets()
+		plan.Errors = result.Errors
+	}
+
+	for _, p := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		promotion, err := planPromotion(src, dst, p)
+		if err != nil {
+			return nil, err
+		}
+		plan.Promotions = append(plan.Promotions, promotion)
+	}
+	return plan, nil
+}
+
+func planPromotion(src, dst *Client, path string) (*PlannedWrite, error) {
+	secret, err := src.GetSecret(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in %s: %w", path, src.EnvName, err)
+	}
+	return dst.PlanWrite(path, secret.Data)
+}
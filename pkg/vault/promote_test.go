@@ -0,0 +1,63 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestPromote(t *testing.T) {
+	devSrv := vaulttest.NewServer(t)
+	prodSrv := vaulttest.NewServer(t)
+	dev := newTestClient(t, devSrv, t.TempDir())
+	prod := newTestClient(t, prodSrv, t.TempDir())
+	prod.EnvName = "prod"
+
+	devSrv.Put("secret/app/api", map[string]interface{}{"key": "new", "url": "https://api"})
+	devSrv.Put("secret/app/db/creds", map[string]interface{}{"user": "app"})
+	devSrv.Put("secret/app/same", map[string]interface{}{"a": "1"})
+	prodSrv.Put("secret/app/api", map[string]interface{}{"key": "old", "debug": "true"})
+	prodSrv.Put("secret/app/same", map[string]interface{}{"a": "1"})
+
+	plan, err := PlanPromotion(context.Background(), dev, prod, "secret/app", true, WalkOptions{})
+	if err != nil {
+		t.Fatalf("PlanPromotion() error: %v", err)
+	}
+	if plan.From != "dev" || plan.To != "prod" {
+		t.Errorf("plan is %s -> %s, want dev -> prod", plan.From, plan.To)
+	}
+
+	pending := plan.Pending()
+	if len(plan.Promotions) != 3 || len(pending) != 2 {
+		t.Fatalf("got %d promotions, %d pending; want 3, 2", len(plan.Promotions), len(pending))
+	}
+
+	api := pending[0]
+	wantChanges := []KeyChange{
+		{Key: "debug", Type: ChangeRemoved},
+		{Key: "key", Type: ChangeChanged},
+		{Key: "url", Type: ChangeAdded},
+	}
+	if api.Path != "secret/app/api" || api.Create || !reflect.DeepEqual(api.Changes, wantChanges) {
+		t.Errorf("api promotion = %+v, want changes %+v", api, wantChanges)
+	}
+	if !pending[1].Create {
+		t.Errorf("db/creds promotion should create the secret")
+	}
+
+	// A write to the target after planning must not be overwritten
+	prodSrv.Put("secret/app/api", map[string]interface{}{"key": "hotfix"})
+	if _, err := prod.ApplyWrite(api); !errors.Is(err, ErrCASMismatch) {
+		t.Errorf("ApplyWrite(stale) error = %v, want ErrCASMismatch", err)
+	}
+
+	if _, err := prod.ApplyWrite(pending[1]); err != nil {
+		t.Fatalf("ApplyWrite() error: %v", err)
+	}
+	if got := prodSrv.Data("secret/app/db/creds"); !reflect.DeepEqual(got, map[string]interface{}{"user": "app"}) {
+		t.Errorf("prod data = %v, want the dev data", got)
+	}
+}
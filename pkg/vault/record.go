@@ -0,0 +1,176 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// Request is a call to the Vault API as a Recorder saw it: the API path after
+// KV rewriting and the keys of the body, never the values
+type Request struct {
+	Method string   `json:"method" yaml:"method"`
+	Path   string   `json:"path" yaml:"path"`
+	Keys   []string `json:"keys,omitempty" yaml:"keys,omitempty"`
+	Unset  []string `json:"unset,omitempty" yaml:"unset,omitempty"` // keys a merge patch removes
+	Sent   bool     `json:"sent" yaml:"sent"`
+}
+
+// Changes reports whether the request writes or deletes something
+func (r Request) Changes() bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, "LIST":
+		return false
+	}
+	return true
+}
+
+func (r Request) String() string {
+	s := r.Method + " " + r.Path
+	var parts []string
+	if len(r.Keys) > 0 {
+		parts = append(parts, "keys: "+strings.Join(r.Keys, ", "))
+	}
+	if len(r.Unset) > 0 {
+		parts = append(parts, "unset: "+strings.Join(r.Unset, ", "))
+	}
+	if len(parts) > 0 {
+		s += " (" + strings.Join(parts, "; ") + ")"
+	}
+	return s
+}
+
+// Recorder is an http.RoundTripper that records the requests passing through
+// it. In a dry run, requests that would change something are recorded and
+// answered with an empty response instead of being sent; reads still go to
+// Vault so paths resolve the same way.
+type Recorder struct {
+	Transport http.RoundTripper
+	DryRun    bool
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := Request{
+		Method: req.Method,
+		Path:   strings.TrimPrefix(req.URL.Path, "/v1/"),
+	}
+	if req.URL.Query().Get("list") == "true" {
+		rec.Method = "LIST"
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		rec.Keys, rec.Unset = bodyKeys(body)
+	}
+
+	rec.Sent = !r.DryRun || !rec.Changes()
+	r.mu.Lock()
+	r.requests = append(r.requests, rec)
+	r.mu.Unlock()
+
+	if !rec.Sent {
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", http.StatusNoContent, http.StatusText(http.StatusNoContent)),
+			StatusCode: http.StatusNoContent,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    req,
+		}, nil
+	}
+	return r.Transport.RoundTrip(req)
+}
+
+// Requests returns the recorded requests in the order they were made
+func (r *Recorder) Requests() []Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Request(nil), r.requests...)
+}
+
+// Withheld returns the requests a dry run didn't send
+func (r *Recorder) Withheld() []Request {
+	withheld := []Request{}
+	for _, req := range r.Requests() {
+		if !req.Sent {
+			withheld = append(withheld, req)
+		}
+	}
+	return withheld
+}
+
+// bodyKeys returns the sorted keys of a JSON body, looking inside the "data"
+// wrapper of KV v2 writes. Keys set to null, which a merge patch removes, are
+// returned separately.
+func bodyKeys(body []byte) ([]string, []string) {
+	var obj map[string]interface{}
+	if json.Unmarshal(body, &obj) != nil {
+		return nil, nil
+	}
+	if data, ok := obj["data"].(map[string]interface{}); ok {
+		obj = data
+	}
+
+	var keys, unset []string
+	for k, v := range obj {
+		if v == nil {
+			unset = append(unset, k)
+		} else {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	sort.Strings(unset)
+	return keys, unset
+}
+
+// Record sends the client's requests through a new Recorder and returns it.
+// With dryRun nothing is changed: writes and deletes are only recorded, logins
+// don't save a token and discovered mounts aren't cached on disk.
+func (c *Client) Record(dryRun bool) (*Recorder, error) {
+	vaultCfg := c.Client.CloneConfig()
+	rec := &Recorder{Transport: vaultCfg.HttpClient.Transport, DryRun: dryRun}
+	vaultCfg.HttpClient.Transport = rec
+
+	api, err := vaultapi.NewClient(vaultCfg)
+	if err != nil {
+		return nil, err
+	}
+	api.SetHeaders(c.Client.Headers())
+	api.SetToken(c.Client.Token())
+
+	c.Client = api
+	c.recorder = rec
+	if c.mounts != nil {
+		c.mounts.readOnly = dryRun
+	}
+	return rec, nil
+}
+
+// Recorder returns the recorder installed with Record, or nil
+func (c *Client) Recorder() *Recorder {
+	return c.recorder
+}
+
+// DryRun reports whether the client only records what it would change
+func (c *Client) DryRun() bool {
+	return c.recorder != nil && c.recorder.DryRun
+}
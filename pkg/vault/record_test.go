@@ -0,0 +1,147 @@
+package vault
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestRecordDryRun(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	srv.Put("secret/app", map[string]interface{}{"user": "app", "password": "old"})
+
+	rec, err := client.Record(true)
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	if err := client.PutSecret("secret/app", map[string]interface{}{"user": "app", "password": "new"}); err != nil {
+		t.Fatalf("PutSecret() error: %v", err)
+	}
+	if _, err := client.PatchSecret("secret/app", map[string]interface{}{"token": "t"}, []string{"user"}); err != nil {
+		t.Fatalf("PatchSecret() error: %v", err)
+	}
+	if err := client.DeleteSecret("secret/app"); err != nil {
+		t.Fatalf("DeleteSecret() error: %v", err)
+	}
+
+	want := []Request{
+		{Method: "PUT", Path: "secret/data/app", Keys: []string{"password", "user"}},
+		{Method: "PATCH", Path: "secret/data/app", Keys: []string{"token"}, Unset: []string{"user"}},
+		{Method: "DELETE", Path: "secret/data/app"},
+	}
+	if got := rec.Withheld(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Withheld() = %+v, want %+v", got, want)
+	}
+
+	if got := srv.Data("secret/app"); got["password"] != "old" {
+		t.Errorf("data after dry run = %v, want it unchanged", got)
+	}
+}
+
+func TestRecordDryRunKeepsToken(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	if _, err := client.Record(true); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	if _, err := client.LoginWithUserPass("app", "secret", false); err != nil {
+		t.Fatalf("LoginWithUserPass() error: %v", err)
+	}
+	if got := client.Recorder().Withheld(); len(got) != 1 || got[0].Path != "auth/userpass/login/app" || !reflect.DeepEqual(got[0].Keys, []string{"password"}) {
+		t.Errorf("Withheld() = %+v, want the login without its password", got)
+	}
+
+	if entry, err := client.Tokens.Load(srv.URL); err != nil || entry != nil {
+		t.Errorf("saved token = %+v, %v; want nothing saved", entry, err)
+	}
+}
+
+func TestRecordDryRunLogoutKeepsToken(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	if err := client.Tokens.Save(srv.URL, &tokenstore.Entry{Token: "s.saved"}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := client.Record(true); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	if err := client.Logout(); err != nil {
+		t.Fatalf("Logout() error: %v", err)
+	}
+	if entry, err := client.Tokens.Load(srv.URL); err != nil || entry == nil || entry.Token != "s.saved" {
+		t.Errorf("saved token = %+v, %v; want it kept", entry, err)
+	}
+}
+
+func TestRecordDryRunSkipsMountCache(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	cacheDir := t.TempDir()
+	client := newTestClient(t, srv, cacheDir)
+	srv.Put("secret/app", map[string]interface{}{"user": "app"})
+	if _, err := client.Record(true); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+
+	if _, err := client.GetSecret("secret/app"); err != nil {
+		t.Fatalf("GetSecret() error: %v", err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) > 0 {
+		t.Errorf("dry run wrote %s to the cache dir", entries[0].Name())
+	}
+}
+
+func TestRecordSendsWithoutDryRun(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	rec, err := client.Record(false)
+	if err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if err := client.PutSecret("secret/app", map[string]interface{}{"key": "value"}); err != nil {
+		t.Fatalf("PutSecret() error: %v", err)
+	}
+
+	if got := srv.Data("secret/app"); got["key"] != "value" {
+		t.Errorf("data = %v, want key=value", got)
+	}
+	var writes []Request
+	for _, r := range rec.Requests() {
+		if r.Changes() {
+			writes = append(writes, r)
+		}
+	}
+	want := []Request{{Method: "PUT", Path: "secret/data/app", Keys: []string{"key"}, Sent: true}}
+	if !reflect.DeepEqual(writes, want) {
+		t.Errorf("recorded writes = %+v, want %+v", writes, want)
+	}
+}
+
+func TestRecordDryRunRollback(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+	srv.Put("secret/app", map[string]interface{}{"password": "old"})
+	srv.Put("secret/app", map[string]interface{}{"password": "new"})
+
+	if _, err := client.Record(true); err != nil {
+		t.Fatalf("Record() error: %v", err)
+	}
+	if _, err := client.RollbackSecret("secret/app", 1); err != nil {
+		t.Fatalf("RollbackSecret() error: %v", err)
+	}
+
+	want := []Request{{Method: "PUT", Path: "secret/data/app", Keys: []string{"password"}}}
+	if got := client.Recorder().Withheld(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Withheld() = %+v, want %+v", got, want)
+	}
+	if got := srv.Data("secret/app")["password"]; got != "new" {
+		t.Errorf("dry run changed the secret: password = %v", got)
+	}
+}
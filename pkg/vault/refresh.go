@@ -0,0 +1,108 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dautovri/ruslan-cli/pkg/auth"
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+var errInsecureCredentialStore = errors.New("saving credentials requires token_store encrypted-file or command")
+
+// refreshToken keeps the saved token usable when auto_refresh is on. A token
+// whose TTL is below the refresh threshold is renewed with renew-self; if it
+// can't be renewed (or is already gone) an approle/userpass login is repeated
+// with the saved credentials. The new expiry is written back to the store.
+func (c *Client) refreshToken() error {
+	if c.entry == nil || c.entry.Token == "" {
+		return nil
+	}
+
+	threshold, err := c.Config.RefreshThresholdDuration()
+	if err != nil {
+		return err
+	}
+
+	info, err := c.Auth().Token().LookupSelf()
+	if err != nil {
+		return c.relogin(fmt.Errorf("saved token is no longer valid: %w", err))
+	}
+
+	ttl, _ := info.TokenTTL()
+	if ttl == 0 {
+		// Tokens without a TTL (e.g. root tokens) never expire
+		return nil
+	}
+	if ttl > threshold {
+		// Tokens saved before expiry tracking get it recorded once
+		if c.entry.ExpiresAt.IsZero() {
+			c.entry.ExpiresAt = time.Now().Add(ttl)
+			return c.saveEntry(c.entry)
+		}
+		return nil
+	}
+
+	if renewable, _ := info.TokenIsRenewable(); renewable {
+		secret, err := c.Auth().Token().RenewSelf(0)
+		if err == nil && secret != nil && secret.Auth != nil {
+			renewed := time.Duration(secret.Auth.LeaseDuration) * time.Second
+			// Renewal is capped by the token's max TTL, so it may not buy enough time
+			if renewed > threshold {
+				c.entry.ExpiresAt = time.Now().Add(renewed)
+				c.entry.Renewable = secret.Auth.Renewable
+				return c.saveEntry(c.entry)
+			}
+		}
+	}
+
+	return c.relogin(fmt.Errorf("saved token expires in %s and can't be renewed, run 'ruslan-cli login'", ttl.Round(time.Second)))
+}
+
+// relogin repeats an approle/userpass login with saved credentials, or
+// returns reason if there is nothing to log in with
+func (c *Client) relogin(reason error) error {
+	creds := c.entry.Credentials
+	if len(creds) == 0 {
+		return reason
+	}
+
+	// An expired token must not be sent along with the login request
+	c.ClearToken()
+
+	var secretAuth *vaultapi.SecretAuth
+	var err error
+	switch c.entry.Method {
+	case tokenstore.MethodAppRole:
+		secretAuth, err = auth.LoginWithAppRole(c.Client, creds["role_id"], creds["secret_id"])
+	case tokenstore.MethodUserPass:
+		secretAuth, err = auth.LoginWithUserPass(c.Client, creds["username"], creds["password"])
+	default:
+		c.SetToken(c.entry.Token)
+		return reason
+	}
+	if err != nil {
+		c.SetToken(c.entry.Token)
+		return fmt.Errorf("%w; re-login with saved %s credentials failed: %v", reason, c.entry.Method, err)
+	}
+
+	entry := entryFromAuth(c.entry.Method, secretAuth)
+	entry.Credentials = creds
+	c.SetToken(entry.Token)
+	return c.saveEntry(entry)
+}
+
+// entryFromAuth builds a store entry from a login response
+func entryFromAuth(method string, secretAuth *vaultapi.SecretAuth) *tokenstore.Entry {
+	entry := &tokenstore.Entry{
+		Token:     secretAuth.ClientToken,
+		Method:    method,
+		Renewable: secretAuth.Renewable,
+	}
+	if secretAuth.LeaseDuration > 0 {
+		entry.ExpiresAt = time.Now().Add(time.Duration(secretAuth.LeaseDuration) * time.Second)
+	}
+	return entry
+}
@@ -0,0 +1,120 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dautovri/ruslan-cli/pkg/config"
+	"github.com/dautovri/ruslan-cli/pkg/tokenstore"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// newRefreshTestClient points a client at handler with the given saved entry
+func newRefreshTestClient(t *testing.T, handler http.HandlerFunc, entry *tokenstore.Entry) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	vaultCfg := vaultapi.DefaultConfig()
+	vaultCfg.Address = server.URL
+	api, err := vaultapi.NewClient(vaultCfg)
+	if err != nil {
+		t.Fatalf("failed to create API client: %v", err)
+	}
+	api.SetToken(entry.Token)
+
+	cfg := config.DefaultConfig()
+	cfg.RefreshThreshold = "5m"
+	return &Client{
+		Client:  api,
+		Config:  cfg,
+		EnvName: "dev",
+		Env:     &config.Environment{VaultAddr: server.URL},
+		Tokens:  tokenstore.NewFileStore(t.TempDir()),
+		entry:   entry,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestRefreshTokenRenews(t *testing.T) {
+	renewed := false
+	client := newRefreshTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"ttl": 60, "renewable": true}})
+		case "/v1/auth/token/renew-self":
+			renewed = true
+			writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": "s.old", "lease_duration": 3600, "renewable": true}})
+		default:
+			http.NotFound(w, r)
+		}
+	}, &tokenstore.Entry{Token: "s.old", Method: tokenstore.MethodToken})
+
+	if err := client.refreshToken(); err != nil {
+		t.Fatalf("refreshToken() error: %v", err)
+	}
+	if !renewed {
+		t.Fatal("expected renew-self to be called")
+	}
+
+	saved, err := client.Tokens.Load(client.Env.VaultAddr)
+	if err != nil || saved == nil {
+		t.Fatalf("Load() = %v, %v", saved, err)
+	}
+	if remaining := time.Until(saved.ExpiresAt); remaining < 59*time.Minute {
+		t.Errorf("saved expiry in %s, want about 1h", remaining)
+	}
+}
+
+func TestRefreshTokenReloginWithAppRole(t *testing.T) {
+	client := newRefreshTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/token/lookup-self":
+			writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"ttl": 30, "renewable": false}})
+		case "/v1/auth/approle/login":
+			if r.Header.Get("X-Vault-Token") != "" {
+				t.Error("expired token was sent with the login request")
+			}
+			writeJSON(w, map[string]interface{}{"auth": map[string]interface{}{"client_token": "s.new", "lease_duration": 1800}})
+		default:
+			http.NotFound(w, r)
+		}
+	}, &tokenstore.Entry{
+		Token:       "s.old",
+		Method:      tokenstore.MethodAppRole,
+		Credentials: map[string]string{"role_id": "role", "secret_id": "secret"},
+	})
+
+	if err := client.refreshToken(); err != nil {
+		t.Fatalf("refreshToken() error: %v", err)
+	}
+	if client.Token() != "s.new" {
+		t.Errorf("client token = %s, want s.new", client.Token())
+	}
+
+	saved, _ := client.Tokens.Load(client.Env.VaultAddr)
+	if saved == nil || saved.Token != "s.new" || saved.Credentials["role_id"] != "role" {
+		t.Errorf("saved entry = %+v, want new token with credentials kept", saved)
+	}
+}
+
+func TestRefreshTokenWithoutCredentials(t *testing.T) {
+	client := newRefreshTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"data": map[string]interface{}{"ttl": 30, "renewable": false}})
+	}, &tokenstore.Entry{Token: "s.old", Method: tokenstore.MethodToken})
+
+	if err := client.refreshToken(); err == nil {
+		t.Error("expected an error for a non-renewable token without credentials")
+	}
+	if client.Token() != "s.old" {
+		t.Errorf("client token = %s, want s.old to be kept", client.Token())
+	}
+}
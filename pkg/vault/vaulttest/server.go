@@ -0,0 +1,472 @@
+// Package vaulttest runs an in-memory Vault server with KV v1 and v2 engines
+// for tests. It implements just enough of the HTTP API for pkg/vault.
+package vaulttest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server is a fake Vault. Paths passed to its helpers are user paths such as
+// "secret/app/db", without the KV v2 data/ segment.
+type Server struct {
+	URL string
+
+	mu           sync.Mutex
+	mountLookups int
+	noPatch      bool
+	denied       []string
+	mounts       map[string]int // mount path ("secret/") to KV version
+	v1           map[string]map[string]interface{}
+	v2           map[string]*secret
+}
+
+type secret struct {
+	current  int
+	versions map[int]*version
+}
+
+type version struct {
+	data      map[string]interface{}
+	created   time.Time
+	deleted   time.Time
+	destroyed bool
+}
+
+// NewServer starts a server with a KV v2 engine at secret/ and closes it when
+// the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	s := &Server{
+		mounts: map[string]int{"secret/": 2},
+		v1:     make(map[string]map[string]interface{}),
+		v2:     make(map[string]*secret),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL
+	return s
+}
+
+// Mount adds a KV engine of the given version (1 or 2) at path
+func (s *Server) Mount(path string, version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mounts[strings.TrimSuffix(path, "/")+"/"] = version
+}
+
+// MountLookups counts requests to sys/internal/ui/mounts
+func (s *Server) MountLookups() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mountLookups
+}
+
+// DisablePatch makes PATCH requests fail like on Vault versions before 1.9
+func (s *Server) DisablePatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noPatch = true
+}
+
+// Deny makes every request below path fail with 403, like a policy without access
+func (s *Server) Deny(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.denied = append(s.denied, path)
+}
+
+// Put writes a new version of a secret directly
+func (s *Server) Put(path string, data map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mount, rel := s.split(path)
+	if s.mounts[mount] == 1 {
+		s.v1[mount+rel] = data
+		return
+	}
+	s.writeV2(mount+rel, data)
+}
+
+// Data returns the latest live data of a secret, or nil
+func (s *Server) Data(path string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mount, rel := s.split(path)
+	if s.mounts[mount] == 1 {
+		return s.v1[mount+rel]
+	}
+	sec := s.v2[mount+rel]
+	if sec == nil {
+		return nil
+	}
+	v := sec.versions[sec.current]
+	if v == nil || !v.deleted.IsZero() || v.destroyed {
+		return nil
+	}
+	return v.data
+}
+
+// split returns the longest mount containing path and the rest of the path
+func (s *Server) split(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/")
+	best := ""
+	for mount := range s.mounts {
+		if (strings.HasPrefix(path, mount) || path+"/" == mount) && len(mount) > len(best) {
+			best = mount
+		}
+	}
+	if best == "" {
+		return "", path
+	}
+	return best, strings.TrimPrefix(strings.TrimPrefix(path, best), "/")
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if strings.HasPrefix(path, "sys/internal/ui/mounts/") {
+		s.mountLookups++
+		s.handleMountLookup(w, strings.TrimPrefix(path, "sys/internal/ui/mounts/"))
+		return
+	}
+
+	mount, rel := s.split(path)
+	if s.isDenied(mount, rel) {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch s.mounts[mount] {
+	case 1:
+		s.handleV1(w, r, mount+rel)
+	case 2:
+		kind, rest, _ := strings.Cut(rel, "/")
+		s.handleV2(w, r, kind, mount+rest)
+	default:
+		writeError(w, http.StatusNotFound, "no handler for route \""+path+"\"")
+	}
+}
+
+// isDenied checks the user path behind an API path against Deny prefixes
+func (s *Server) isDenied(mount, rel string) bool {
+	if s.mounts[mount] == 2 {
+		_, rel, _ = strings.Cut(rel, "/")
+	}
+	for _, prefix := range s.denied {
+		if strings.HasPrefix(mount+rel+"/", strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) handleMountLookup(w http.ResponseWriter, path string) {
+	mount, _ := s.split(path)
+	if mount == "" {
+		writeError(w, http.StatusBadRequest, "no mount found for path")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"path":    mount,
+			"type":    "kv",
+			"options": map[string]interface{}{"version": strconv.Itoa(s.mounts[mount])},
+		},
+	})
+}
+
+func (s *Server) handleV1(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case isList(r):
+		s.writeList(w, path, keys(s.v1))
+	case r.Method == http.MethodGet:
+		data, ok := s.v1[path]
+		if !ok {
+			writeError(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.v1[path] = body
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(s.v1, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleV2(w http.ResponseWriter, r *http.Request, kind, path string) {
+	switch {
+	case kind == "metadata" && isList(r):
+		s.writeList(w, path, keys(s.v2))
+	case kind == "metadata" && r.Method == http.MethodGet:
+		s.readMetadata(w, path)
+	case kind == "data" && r.Method == http.MethodGet:
+		s.readV2(w, r, path)
+	case kind == "data" && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		var body struct {
+			Data    map[string]interface{} `json:"data"`
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if body.Options.CAS != nil && *body.Options.CAS != s.currentVersion(path) {
+			writeError(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+		v := s.writeV2(path, body.Data)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": versionMetadata(s.v2[path], v)})
+	case kind == "data" && r.Method == http.MethodDelete:
+		if sec := s.v2[path]; sec != nil {
+			sec.versions[sec.current].deleted = time.Now()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case kind == "data" && r.Method == http.MethodPatch:
+		s.patchV2(w, r, path)
+	case kind == "metadata" && r.Method == http.MethodDelete:
+		delete(s.v2, path)
+		w.WriteHeader(http.StatusNoContent)
+	case (kind == "delete" || kind == "undelete" || kind == "destroy") &&
+		(r.Method == http.MethodPut || r.Method == http.MethodPost):
+		var body struct {
+			Versions []int `json:"versions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.updateVersions(kind, path, body.Versions)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) readV2(w http.ResponseWriter, r *http.Request, path string) {
+	sec := s.v2[path]
+	if sec == nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	n := sec.current
+	if q := r.URL.Query().Get("version"); q != "" && q != "0" {
+		n, _ = strconv.Atoi(q)
+	}
+	v := sec.versions[n]
+	if v == nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	body := map[string]interface{}{"data": nil, "metadata": versionMetadata(sec, n)}
+	if !v.deleted.IsZero() || v.destroyed {
+		// Vault answers 404 but still returns the version metadata
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"data": body})
+		return
+	}
+	body["data"] = v.data
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": body})
+}
+
+func (s *Server) readMetadata(w http.ResponseWriter, path string) {
+	sec := s.v2[path]
+	if sec == nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	versions := make(map[string]interface{}, len(sec.versions))
+	oldest := sec.current
+	for n := range sec.versions {
+		meta := versionMetadata(sec, n)
+		delete(meta, "version")
+		delete(meta, "custom_metadata")
+		versions[strconv.Itoa(n)] = meta
+		if n < oldest {
+			oldest = n
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{
+		"current_version": sec.current,
+		"oldest_version":  oldest,
+		"custom_metadata": nil,
+		"versions":        versions,
+	}})
+}
+
+func (s *Server) patchV2(w http.ResponseWriter, r *http.Request, path string) {
+	if s.noPatch {
+		writeError(w, http.StatusMethodNotAllowed, "unsupported operation")
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/merge-patch+json" {
+		writeError(w, http.StatusUnsupportedMediaType, "PATCH requires application/merge-patch+json")
+		return
+	}
+
+	sec := s.v2[path]
+	if sec == nil || sec.versions[sec.current].data == nil || !sec.versions[sec.current].deleted.IsZero() {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range sec.versions[sec.current].data {
+		data[k] = v
+	}
+	for k, v := range body.Data {
+		if v == nil {
+			delete(data, k)
+			continue
+		}
+		data[k] = v
+	}
+
+	v := s.writeV2(path, data)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": versionMetadata(sec, v)})
+}
+
+// updateVersions applies a delete, undelete or destroy to versions of path
+func (s *Server) updateVersions(op, path string, versions []int) {
+	sec := s.v2[path]
+	if sec == nil {
+		return
+	}
+	for _, n := range versions {
+		v := sec.versions[n]
+		if v == nil {
+			continue
+		}
+		switch op {
+		case "delete":
+			v.deleted = time.Now()
+		case "undelete":
+			if !v.destroyed {
+				v.deleted = time.Time{}
+			}
+		case "destroy":
+			v.destroyed = true
+			v.data = nil
+		}
+	}
+}
+
+// currentVersion returns the latest version of path, 0 if it doesn't exist
+func (s *Server) currentVersion(path string) int {
+	if sec := s.v2[path]; sec != nil {
+		return sec.current
+	}
+	return 0
+}
+
+func (s *Server) writeV2(path string, data map[string]interface{}) int {
+	sec := s.v2[path]
+	if sec == nil {
+		sec = &secret{versions: make(map[int]*version)}
+		s.v2[path] = sec
+	}
+	sec.current++
+	sec.versions[sec.current] = &version{data: data, created: time.Now()}
+	return sec.current
+}
+
+func versionMetadata(sec *secret, n int) map[string]interface{} {
+	v := sec.versions[n]
+	deletion := ""
+	if !v.deleted.IsZero() {
+		deletion = v.deleted.UTC().Format(time.RFC3339Nano)
+	}
+	return map[string]interface{}{
+		"version":         n,
+		"created_time":    v.created.UTC().Format(time.RFC3339Nano),
+		"deletion_time":   deletion,
+		"destroyed":       v.destroyed,
+		"custom_metadata": nil,
+	}
+}
+
+// writeList answers a LIST with the direct children of prefix among paths
+func (s *Server) writeList(w http.ResponseWriter, prefix string, paths []string) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		name, _, isFolder := strings.Cut(strings.TrimPrefix(p, prefix), "/")
+		if isFolder {
+			name += "/"
+		}
+		seen[name] = true
+	}
+	if len(seen) == 0 {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"keys": keys(seen)}})
+}
+
+// keys returns the sorted keys of m
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func isList(r *http.Request) bool {
+	return r.Method == "LIST" || (r.Method == http.MethodGet && r.URL.Query().Get("list") == "true")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+	writeJSON(w, status, map[string]interface{}{"errors": errs})
+}
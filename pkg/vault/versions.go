@@ -0,0 +1,156 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// SecretVersion describes one KV v2 version of a secret
+type SecretVersion struct {
+	Version      int        `json:"version" yaml:"version"`
+	CreatedTime  time.Time  `json:"created_time" yaml:"created_time"`
+	DeletionTime *time.Time `json:"deletion_time" yaml:"deletion_time"`
+	Destroyed    bool       `json:"destroyed" yaml:"destroyed"`
+}
+
+// Deleted reports whether the version was soft-deleted
+func (v SecretVersion) Deleted() bool {
+	return v.DeletionTime != nil
+}
+
+// SecretMetadata is the KV v2 metadata of a secret
+type SecretMetadata struct {
+	Path           string            `json:"path" yaml:"path"`
+	CurrentVersion int               `json:"current_version" yaml:"current_version"`
+	OldestVersion  int               `json:"oldest_version" yaml:"oldest_version"`
+	CustomMetadata map[string]string `json:"custom_metadata,omitempty" yaml:"custom_metadata,omitempty"`
+	Versions       []SecretVersion   `json:"versions" yaml:"versions"`
+}
+
+// GetSecretVersion reads a specific KV v2 version of a secret; 0 means latest
+func (c *Client) GetSecretVersion(path string, version int) (*Secret, error) {
+	p := c.resolvePath(path)
+	if version > 0 && !p.isV2() {
+		return nil, errNotV2(p, "versions")
+	}
+
+	var secret *vaultapi.Secret
+	var err error
+	if version > 0 {
+		secret, err = c.Logical().ReadWithData(p.data(), map[string][]string{
+			"version": {strconv.Itoa(version)},
+		})
+	} else {
+		secret, err = c.Logical().Read(p.data())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseSecret(p, secret)
+}
+
+// ReadMetadata returns the version history of a KV v2 secret, oldest first
+func (c *Client) ReadMetadata(path string) (*SecretMetadata, error) {
+	p := c.resolvePath(path)
+	if !p.isV2() {
+		return nil, errNotV2(p, "versions")
+	}
+
+	secret, err := c.Logical().Read(p.metadata())
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("%w at %s", ErrSecretNotFound, p)
+	}
+
+	meta := &SecretMetadata{
+		Path:           p.String(),
+		CurrentVersion: jsonInt(secret.Data["current_version"]),
+		OldestVersion:  jsonInt(secret.Data["oldest_version"]),
+	}
+	if custom, ok := secret.Data["custom_metadata"].(map[string]interface{}); ok {
+		meta.CustomMetadata = make(map[string]string, len(custom))
+		for k, v := range custom {
+			meta.CustomMetadata[k] = fmt.Sprint(v)
+		}
+	}
+
+	versions, _ := secret.Data["versions"].(map[string]interface{})
+	for key, raw := range versions {
+		fields, _ := raw.(map[string]interface{})
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+
+		v := SecretVersion{Version: n}
+		v.CreatedTime = parseTime(fields["created_time"])
+		if deleted := parseTime(fields["deletion_time"]); !deleted.IsZero() {
+			v.DeletionTime = &deleted
+		}
+		v.Destroyed, _ = fields["destroyed"].(bool)
+		meta.Versions = append(meta.Versions, v)
+	}
+	sort.Slice(meta.Versions, func(i, j int) bool { return meta.Versions[i].Version < meta.Versions[j].Version })
+
+	return meta, nil
+}
+
+// RollbackSecret writes the data of an older version as the new current
+// version and returns the new version number. The write uses check-and-set,
+// so it fails if someone else wrote the secret in the meantime.
+func (c *Client) RollbackSecret(path string, version int) (int, error) {
+	p := c.resolvePath(path)
+	if !p.isV2() {
+		return 0, errNotV2(p, "rollback")
+	}
+
+	meta, err := c.ReadMetadata(path)
+	if err != nil {
+		return 0, err
+	}
+	if version == meta.CurrentVersion {
+		return 0, fmt.Errorf("version %d is already the current version of %s", version, p)
+	}
+
+	old, err := c.GetSecretVersion(path, version)
+	if err != nil {
+		return 0, fmt.Errorf("can't read version %d: %w", version, err)
+	}
+
+	return c.writeData(p, old.Data, map[string]interface{}{"cas": meta.CurrentVersion})
+}
+
+func errNotV2(p kvPath, feature string) error {
+	return fmt.Errorf("%s is not a KV v2 path: %s are only supported on KV v2", p, feature)
+}
+
+// jsonInt converts a number from a Vault response to an int
+func jsonInt(v interface{}) int {
+	switch n := v.(type) {
+	case json.Number:
+		i, _ := n.Int64()
+		return int(i)
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+// parseTime parses an RFC 3339 time from a Vault response; empty means zero
+func parseTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
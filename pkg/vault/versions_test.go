@@ -0,0 +1,61 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestVersionsAndRollback(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	srv.Put("secret/app", map[string]interface{}{"password": "one"})
+	srv.Put("secret/app", map[string]interface{}{"password": "two"})
+	srv.Put("secret/app", map[string]interface{}{"password": "bad"})
+
+	meta, err := client.ReadMetadata("secret/app")
+	if err != nil {
+		t.Fatalf("ReadMetadata() error: %v", err)
+	}
+	if meta.CurrentVersion != 3 || len(meta.Versions) != 3 || meta.Versions[0].Version != 1 {
+		t.Fatalf("ReadMetadata() = %+v, want 3 versions oldest first", meta)
+	}
+
+	old, err := client.GetSecretVersion("secret/app", 2)
+	if err != nil {
+		t.Fatalf("GetSecretVersion() error: %v", err)
+	}
+	if old.Data["password"] != "two" || old.Version != 2 {
+		t.Errorf("GetSecretVersion(2) = %+v, want password=two", old)
+	}
+
+	version, err := client.RollbackSecret("secret/app", 2)
+	if err != nil {
+		t.Fatalf("RollbackSecret() error: %v", err)
+	}
+	if version != 4 {
+		t.Errorf("RollbackSecret() version = %d, want 4", version)
+	}
+	if got := srv.Data("secret/app")["password"]; got != "two" {
+		t.Errorf("current password = %v, want two", got)
+	}
+
+	if _, err := client.RollbackSecret("secret/app", 4); err == nil {
+		t.Error("expected rolling back to the current version to fail")
+	}
+}
+
+func TestVersionsRequireKVv2(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	srv.Mount("legacy", 1)
+	client := newTestClient(t, srv, t.TempDir())
+	srv.Put("legacy/app", map[string]interface{}{"password": "one"})
+
+	if _, err := client.GetSecretVersion("legacy/app", 1); err == nil {
+		t.Error("expected a versioned read on KV v1 to fail")
+	}
+	if _, err := client.ReadMetadata("legacy/app"); err == nil {
+		t.Error("expected ReadMetadata on KV v1 to fail")
+	}
+}
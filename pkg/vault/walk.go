@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultWalkConcurrency is the number of parallel LIST requests when walking
+const DefaultWalkConcurrency = 8
+
+// WalkOptions controls a recursive listing
+type WalkOptions struct {
+	// MaxDepth limits how deep the walk goes; 1 lists only the root's children.
+	// 0 means unlimited.
+	MaxDepth int
+	// Concurrency bounds parallel LIST requests (DefaultWalkConcurrency if 0)
+	Concurrency int
+}
+
+// WalkEntry is a secret or folder found by Walk
+type WalkEntry struct {
+	Path  string `json:"path" yaml:"path"` // folders end with a slash
+	Depth int    `json:"depth" yaml:"depth"`
+	IsDir bool   `json:"is_dir" yaml:"is_dir"`
+}
+
+// Name returns the last path segment, keeping a folder's trailing slash
+func (e WalkEntry) Name() string {
+	trimmed := strings.TrimSuffix(e.Path, "/")
+	name := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	if e.IsDir {
+		name += "/"
+	}
+	return name
+}
+
+// WalkError is a folder that couldn't be listed, e.g. for lack of permission
+type WalkError struct {
+	Path    string `json:"path" yaml:"path"`
+	Message string `json:"error" yaml:"error"`
+}
+
+// Error implements error
+func (e WalkError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// WalkResult holds everything found under the root, sorted by path, and the
+// folders that failed. Failures in sub-folders don't stop the walk.
+type WalkResult struct {
+	Root    string      `json:"root" yaml:"root"`
+	Entries []WalkEntry `json:"entries" yaml:"entries"`
+	Errors  []WalkError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// Secrets returns the paths of the secrets (not folders) found
+func (r *WalkResult) Secrets() []string {
+	paths := make([]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		if !e.IsDir {
+			paths = append(paths, e.Path)
+		}
+	}
+	return paths
+}
+
+// Walk lists root recursively with a bounded number of concurrent requests.
+// An error listing root itself is returned; errors below it are collected in
+// the result.
+func (c *Client) Walk(ctx context.Context, root string, opts WalkOptions) (*WalkResult, error) {
+	root = strings.TrimPrefix(root, "/")
+	if root != "" && !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+
+	keys, err := c.ListSecrets(root)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultWalkConcurrency
+	}
+
+	w := &walker{
+		client:   c,
+		ctx:      ctx,
+		maxDepth: opts.MaxDepth,
+		sem:      make(chan struct{}, concurrency),
+		result:   &WalkResult{Root: root},
+	}
+	w.add(root, keys, 1)
+	w.wg.Wait()
+
+	sort.Slice(w.result.Entries, func(i, j int) bool { return w.result.Entries[i].Path < w.result.Entries[j].Path })
+	sort.Slice(w.result.Errors, func(i, j int) bool { return w.result.Errors[i].Path < w.result.Errors[j].Path })
+	return w.result, ctx.Err()
+}
+
+type walker struct {
+	client   *Client
+	ctx      context.Context
+	maxDepth int
+	sem      chan struct{}
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	result *WalkResult
+}
+
+// add records the keys listed in folder and descends into sub-folders
+func (w *walker) add(folder string, keys []string, depth int) {
+	for _, key := range keys {
+		entry := WalkEntry{Path: folder + key, Depth: depth, IsDir: strings.HasSuffix(key, "/")}
+
+		w.mu.Lock()
+		w.result.Entries = append(w.result.Entries, entry)
+		w.mu.Unlock()
+
+		if entry.IsDir && (w.maxDepth == 0 || depth < w.maxDepth) {
+			w.wg.Add(1)
+			go w.list(entry.Path, depth+1)
+		}
+	}
+}
+
+func (w *walker) list(folder string, depth int) {
+	defer w.wg.Done()
+
+	select {
+	case w.sem <- struct{}{}:
+	case <-w.ctx.Done():
+		return
+	}
+	keys, err := w.client.ListSecrets(folder)
+	<-w.sem
+
+	if err != nil {
+		w.mu.Lock()
+		w.result.Errors = append(w.result.Errors, WalkError{Path: folder, Message: err.Error()})
+		w.mu.Unlock()
+		return
+	}
+	w.add(folder, keys, depth)
+}
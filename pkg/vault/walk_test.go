@@ -0,0 +1,53 @@
+package vault
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/dautovri/ruslan-cli/pkg/vault/vaulttest"
+)
+
+func TestWalk(t *testing.T) {
+	srv := vaulttest.NewServer(t)
+	client := newTestClient(t, srv, t.TempDir())
+
+	for _, path := range []string{
+		"secret/app/api",
+		"secret/app/db/primary",
+		"secret/app/db/replica",
+		"secret/app/private/key",
+		"secret/app/deep/a/b/c",
+	} {
+		srv.Put(path, map[string]interface{}{"k": "v"})
+	}
+	srv.Deny("secret/app/private/")
+
+	result, err := client.Walk(context.Background(), "secret/app", WalkOptions{Concurrency: 2})
+	if err != nil {
+		t.Fatalf("Walk() error: %v", err)
+	}
+
+	wantSecrets := []string{
+		"secret/app/api",
+		"secret/app/db/primary",
+		"secret/app/db/replica",
+		"secret/app/deep/a/b/c",
+	}
+	if got := result.Secrets(); !reflect.DeepEqual(got, wantSecrets) {
+		t.Errorf("Secrets() = %v, want %v", got, wantSecrets)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Path != "secret/app/private/" {
+		t.Errorf("Errors = %v, want one error for secret/app/private/", result.Errors)
+	}
+
+	shallow, err := client.Walk(context.Background(), "secret/app/", WalkOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Walk(depth 2) error: %v", err)
+	}
+	for _, e := range shallow.Entries {
+		if e.Depth > 2 {
+			t.Errorf("entry %s at depth %d beyond MaxDepth 2", e.Path, e.Depth)
+		}
+	}
+}